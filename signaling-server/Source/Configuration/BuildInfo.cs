@@ -0,0 +1,54 @@
+using System.Reflection;
+using System.Runtime.InteropServices;
+
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Identifies the running build. The commit and build time are stamped into the assembly at
+/// publish time (<c>-p:GitCommit=... -p:BuildTime=...</c>); without them the commit falls back
+/// to the source revision the SDK appends to the informational version.
+/// </summary>
+/// <param name="Commit">The git commit the build was made from.</param>
+/// <param name="BuildTime">When the build was made, as passed to the build.</param>
+/// <param name="Runtime">The .NET runtime the server is running on.</param>
+public record BuildInfo(string Commit, string BuildTime, string Runtime)
+{
+    private const string Unknown = "unknown";
+
+    public static readonly BuildInfo Current = FromAssembly(typeof(BuildInfo).Assembly);
+
+    public static BuildInfo FromAssembly(Assembly assembly)
+    {
+        var metadata = assembly
+            .GetCustomAttributes<AssemblyMetadataAttribute>()
+            .ToDictionary(attribute => attribute.Key, attribute => attribute.Value);
+
+        return Resolve(
+            metadata.GetValueOrDefault("GitCommit"),
+            metadata.GetValueOrDefault("BuildTime"),
+            assembly.GetCustomAttribute<AssemblyInformationalVersionAttribute>()
+                ?.InformationalVersion
+        );
+    }
+
+    /// <summary>
+    /// Prefers the explicitly stamped values and falls back to the informational version
+    /// ("1.0.0+&lt;commit&gt;") for the commit.
+    /// </summary>
+    public static BuildInfo Resolve(
+        string? gitCommit,
+        string? buildTime,
+        string? informationalVersion
+    )
+    {
+        var commit = string.IsNullOrWhiteSpace(gitCommit)
+            ? informationalVersion?.Split('+', 2).ElementAtOrDefault(1)
+            : gitCommit;
+
+        return new BuildInfo(
+            string.IsNullOrWhiteSpace(commit) ? Unknown : commit,
+            string.IsNullOrWhiteSpace(buildTime) ? Unknown : buildTime,
+            RuntimeInformation.FrameworkDescription
+        );
+    }
+}
@@ -0,0 +1,70 @@
+using System.Threading.RateLimiting;
+using Microsoft.AspNetCore.RateLimiting;
+using SignalingServer.Extensions;
+
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Per-IP token bucket limiting how often a client may open new WebSocket connections.
+/// Rejected upgrades get HTTP 429 before any connection state is allocated.
+/// </summary>
+public static class ConnectionRateLimiting
+{
+    public const string PolicyName = "websocket-connections";
+
+    private static readonly int ConnectionsPerMinute = int.Parse(
+        Environment.GetEnvironmentVariable("WS_CONNECTIONS_PER_MINUTE") ?? "30"
+    );
+    private static readonly int ConnectionBurst = int.Parse(
+        Environment.GetEnvironmentVariable("WS_CONNECTION_BURST") ?? "10"
+    );
+    private static readonly bool TrustForwardedHeaders = bool.Parse(
+        Environment.GetEnvironmentVariable("TRUST_FORWARDED_HEADERS") ?? "false"
+    );
+    private static readonly int TrustedProxyHops = int.Parse(
+        Environment.GetEnvironmentVariable("TRUSTED_PROXY_HOPS") ?? "1"
+    );
+
+    public static IServiceCollection AddConnectionRateLimiting(this IServiceCollection services)
+    {
+        return services.AddRateLimiter(options =>
+        {
+            options.RejectionStatusCode = StatusCodes.Status429TooManyRequests;
+            options.AddPolicy(
+                PolicyName,
+                context =>
+                    CreatePartition(
+                        context,
+                        ConnectionsPerMinute,
+                        ConnectionBurst,
+                        TrustForwardedHeaders,
+                        TrustedProxyHops
+                    )
+            );
+        });
+    }
+
+    /// <summary>
+    /// Creates the rate limit partition for a request, keyed by client IP.
+    /// </summary>
+    public static RateLimitPartition<string> CreatePartition(
+        HttpContext context,
+        int connectionsPerMinute,
+        int burst,
+        bool trustForwardedHeaders,
+        int trustedProxyHops = 1
+    )
+    {
+        return RateLimitPartition.GetTokenBucketLimiter(
+            context.GetClientIp(trustForwardedHeaders, trustedProxyHops),
+            _ => new TokenBucketRateLimiterOptions
+            {
+                TokenLimit = burst,
+                TokensPerPeriod = connectionsPerMinute,
+                ReplenishmentPeriod = TimeSpan.FromMinutes(1),
+                QueueLimit = 0,
+                AutoReplenishment = true,
+            }
+        );
+    }
+}
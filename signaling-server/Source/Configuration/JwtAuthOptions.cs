@@ -0,0 +1,39 @@
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Settings for optional bearer token authentication of WebSocket connections.
+/// Authentication is enabled when either an HMAC secret or a JWKS URL is configured.
+/// </summary>
+/// <param name="HmacSecret">Shared secret for HS256-signed tokens (AUTH_HMAC_SECRET).</param>
+/// <param name="JwksUrl">JSON Web Key Set with the signing keys (AUTH_JWKS_URL).</param>
+/// <param name="Issuer">Expected "iss" claim, or null to skip the check (AUTH_ISSUER).</param>
+/// <param name="Audience">Expected "aud" claim, or null to skip the check (AUTH_AUDIENCE).</param>
+/// <param name="JwksRefreshInterval">How long fetched JWKS keys are cached.</param>
+public record JwtAuthOptions(
+    string? HmacSecret,
+    string? JwksUrl,
+    string? Issuer,
+    string? Audience,
+    TimeSpan JwksRefreshInterval
+)
+{
+    public bool IsEnabled => HmacSecret != null || JwksUrl != null;
+
+    public static JwtAuthOptions FromEnvironment()
+    {
+        var refreshSeconds = int.Parse(
+            Environment.GetEnvironmentVariable("AUTH_JWKS_REFRESH_SECONDS") ?? "600"
+        );
+
+        return new JwtAuthOptions(
+            NullIfEmpty(Environment.GetEnvironmentVariable("AUTH_HMAC_SECRET")),
+            NullIfEmpty(Environment.GetEnvironmentVariable("AUTH_JWKS_URL")),
+            NullIfEmpty(Environment.GetEnvironmentVariable("AUTH_ISSUER")),
+            NullIfEmpty(Environment.GetEnvironmentVariable("AUTH_AUDIENCE")),
+            TimeSpan.FromSeconds(refreshSeconds)
+        );
+    }
+
+    private static string? NullIfEmpty(string? value) =>
+        string.IsNullOrWhiteSpace(value) ? null : value;
+}
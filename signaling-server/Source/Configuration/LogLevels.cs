@@ -0,0 +1,40 @@
+using Serilog.Events;
+
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Parses the LOG_LEVEL setting. Accepts Serilog level names as well as the short and
+/// Microsoft.Extensions.Logging spellings operators tend to use, such as "info" or "critical".
+/// </summary>
+public static class LogLevels
+{
+    private static readonly Dictionary<string, LogEventLevel> Aliases = new(
+        StringComparer.OrdinalIgnoreCase
+    )
+    {
+        ["trace"] = LogEventLevel.Verbose,
+        ["debug"] = LogEventLevel.Debug,
+        ["info"] = LogEventLevel.Information,
+        ["warn"] = LogEventLevel.Warning,
+        ["err"] = LogEventLevel.Error,
+        ["crit"] = LogEventLevel.Fatal,
+        ["critical"] = LogEventLevel.Fatal,
+    };
+
+    /// <returns><c>false</c> if the value is empty or not a known level.</returns>
+    public static bool TryParse(string? value, out LogEventLevel level)
+    {
+        level = default;
+        if (string.IsNullOrWhiteSpace(value))
+            return false;
+
+        value = value.Trim();
+        if (Aliases.TryGetValue(value, out level))
+            return true;
+
+        // Names only: Enum.TryParse would also take any number
+        return !value.All(char.IsAsciiDigit)
+            && Enum.TryParse(value, ignoreCase: true, out level)
+            && Enum.IsDefined(level);
+    }
+}
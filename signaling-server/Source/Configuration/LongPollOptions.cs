@@ -0,0 +1,32 @@
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Settings for the HTTP long-poll fallback transport.
+/// </summary>
+/// <param name="PollTimeout">How long a receive request waits for a message before returning empty (LONG_POLL_TIMEOUT_SECONDS).</param>
+/// <param name="SessionTimeout">How long a session may go without polling before it is dropped; keep it above the poll timeout.</param>
+/// <param name="QueueSize">Messages queued in each direction before the session is dropped as a slow consumer.</param>
+/// <param name="MaxMessageSize">Largest message a peer may post, in bytes; shares WEBSOCKET_MAX_MESSAGE_SIZE with the WebSocket transport.</param>
+public record LongPollOptions(
+    TimeSpan PollTimeout,
+    TimeSpan SessionTimeout,
+    int QueueSize,
+    int MaxMessageSize = 65536
+)
+{
+    public static LongPollOptions FromEnvironment()
+    {
+        return new LongPollOptions(
+            TimeSpan.FromSeconds(
+                int.Parse(Environment.GetEnvironmentVariable("LONG_POLL_TIMEOUT_SECONDS") ?? "25")
+            ),
+            TimeSpan.FromSeconds(
+                int.Parse(
+                    Environment.GetEnvironmentVariable("LONG_POLL_SESSION_TIMEOUT_SECONDS") ?? "60"
+                )
+            ),
+            int.Parse(Environment.GetEnvironmentVariable("LONG_POLL_QUEUE_SIZE") ?? "256"),
+            int.Parse(Environment.GetEnvironmentVariable("WEBSOCKET_MAX_MESSAGE_SIZE") ?? "65536")
+        );
+    }
+}
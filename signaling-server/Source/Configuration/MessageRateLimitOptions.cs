@@ -0,0 +1,46 @@
+using System.Threading.RateLimiting;
+
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Per-connection token bucket bounding how fast an established socket may send messages.
+/// Unlike <see cref="ConnectionRateLimiting"/>, which limits how often an IP opens connections,
+/// this protects a room from a single peer flooding it, e.g. with ICE candidates.
+/// </summary>
+/// <param name="MessagesPerSecond">Sustained message rate (MESSAGE_RATE_PER_SECOND); zero or less disables the limit.</param>
+/// <param name="Burst">Messages accepted back to back before the rate applies (MESSAGE_RATE_BURST).</param>
+/// <param name="MaxViolations">Rejected messages after which the connection is closed (MESSAGE_RATE_MAX_VIOLATIONS); zero keeps it open.</param>
+public record MessageRateLimitOptions(int MessagesPerSecond, int Burst, int MaxViolations)
+{
+    public bool IsEnabled => MessagesPerSecond > 0;
+
+    public static MessageRateLimitOptions FromEnvironment()
+    {
+        return new MessageRateLimitOptions(
+            int.Parse(Environment.GetEnvironmentVariable("MESSAGE_RATE_PER_SECOND") ?? "50"),
+            int.Parse(Environment.GetEnvironmentVariable("MESSAGE_RATE_BURST") ?? "100"),
+            int.Parse(Environment.GetEnvironmentVariable("MESSAGE_RATE_MAX_VIOLATIONS") ?? "0")
+        );
+    }
+
+    /// <summary>
+    /// Creates the bucket for one connection, or <c>null</c> if the limit is disabled.
+    /// </summary>
+    public TokenBucketRateLimiter? CreateLimiter()
+    {
+        if (!IsEnabled)
+            return null;
+
+        // Replenished by the receive loop rather than by a timer, so idle connections cost nothing
+        return new TokenBucketRateLimiter(
+            new TokenBucketRateLimiterOptions
+            {
+                TokenLimit = Burst,
+                TokensPerPeriod = MessagesPerSecond,
+                ReplenishmentPeriod = TimeSpan.FromSeconds(1),
+                QueueLimit = 0,
+                AutoReplenishment = false,
+            }
+        );
+    }
+}
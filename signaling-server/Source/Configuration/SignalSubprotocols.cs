@@ -0,0 +1,44 @@
+using System.Net.WebSockets;
+
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// WebSocket subprotocols (Sec-WebSocket-Protocol) the server speaks. Clients advertise a
+/// protocol version during the upgrade; clients that advertise none get <see cref="V1"/>.
+/// </summary>
+public static class SignalSubprotocols
+{
+    public const string V1 = "signal.v1";
+
+    public static readonly IReadOnlyList<string> Supported = (
+        Environment.GetEnvironmentVariable("SUPPORTED_SUBPROTOCOLS") ?? V1
+    ).Split(',', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries);
+
+    /// <summary>
+    /// Picks the first requested subprotocol that the server supports.
+    /// </summary>
+    /// <param name="requested">Subprotocols offered by the client, in order of preference.</param>
+    /// <param name="supported">Subprotocols the server accepts.</param>
+    /// <param name="selected">The negotiated subprotocol, or null if the client offered none.</param>
+    /// <returns><c>false</c> if the client offered subprotocols but none are supported.</returns>
+    public static bool TryNegotiate(
+        IEnumerable<string> requested,
+        IReadOnlyCollection<string> supported,
+        out string? selected
+    )
+    {
+        var offered = requested.ToList();
+        selected = offered.FirstOrDefault(protocol =>
+            supported.Contains(protocol, StringComparer.OrdinalIgnoreCase)
+        );
+
+        return offered.Count == 0 || selected != null;
+    }
+
+    /// <summary>
+    /// Returns the protocol version negotiated for a connection, so message handling can
+    /// branch on it.
+    /// </summary>
+    public static string GetProtocol(WebSocket socket) =>
+        string.IsNullOrEmpty(socket.SubProtocol) ? V1 : socket.SubProtocol;
+}
@@ -0,0 +1,36 @@
+namespace SignalingServer.Configuration;
+
+/// <summary>
+/// Settings for the optional lifecycle event webhook. It is enabled when a URL is configured.
+/// </summary>
+/// <param name="Url">Endpoint that receives the events as JSON POSTs (WEBHOOK_URL).</param>
+/// <param name="Secret">Key for the HMAC-SHA256 body signature, or null to send unsigned (WEBHOOK_SECRET).</param>
+/// <param name="QueueSize">Events buffered while the endpoint is slow; newer ones are dropped beyond it.</param>
+/// <param name="MaxAttempts">Deliveries tried per event before it is given up.</param>
+/// <param name="RetryDelay">Wait before the first retry, doubled for each further one.</param>
+public record WebhookOptions(
+    Uri? Url,
+    string? Secret,
+    int QueueSize,
+    int MaxAttempts,
+    TimeSpan RetryDelay
+)
+{
+    public bool IsEnabled => Url != null;
+
+    public static WebhookOptions FromEnvironment()
+    {
+        var url = Environment.GetEnvironmentVariable("WEBHOOK_URL");
+        var secret = Environment.GetEnvironmentVariable("WEBHOOK_SECRET");
+
+        return new WebhookOptions(
+            string.IsNullOrWhiteSpace(url) ? null : new Uri(url),
+            string.IsNullOrWhiteSpace(secret) ? null : secret,
+            int.Parse(Environment.GetEnvironmentVariable("WEBHOOK_QUEUE_SIZE") ?? "1000"),
+            int.Parse(Environment.GetEnvironmentVariable("WEBHOOK_MAX_ATTEMPTS") ?? "3"),
+            TimeSpan.FromMilliseconds(
+                int.Parse(Environment.GetEnvironmentVariable("WEBHOOK_RETRY_DELAY_MS") ?? "500")
+            )
+        );
+    }
+}
@@ -0,0 +1,283 @@
+using System.Net.WebSockets;
+using System.Security.Cryptography;
+using System.Text;
+using Microsoft.AspNetCore.Mvc;
+using SignalingServer.Configuration;
+using SignalingServer.Extensions;
+using SignalingServer.Models;
+using SignalingServer.Services;
+
+namespace SignalingServer.Endpoints;
+
+public static class AdminEndpoints
+{
+    private const string KickedReason = "kicked";
+    private const string MigratingReason = "migrating";
+
+    // RFC 6455 limits the close reason to 123 bytes
+    private const int MaxCloseReasonBytes = 123;
+
+    private static readonly string? AdminToken = Environment.GetEnvironmentVariable("ADMIN_TOKEN");
+    private static readonly TimeSpan MigrationGracePeriod = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("MIGRATION_GRACE_SECONDS") ?? "5")
+    );
+
+    public static void MapAdminEndpoints(this WebApplication app)
+    {
+        var admin = app.MapGroup("/admin");
+        admin.AddEndpointFilter(RequireAdminToken);
+
+        admin.MapGet("/namespaces", GetNamespaces);
+        admin.MapGet("/rooms", GetRooms);
+        admin.MapGet("/rooms/{hostId}", GetRoom);
+        admin.MapDelete("/rooms/{hostId}/peers/{peerId}", KickPeer);
+        admin.MapPost("/rooms/{hostId}/migrate", MigrateRoom);
+    }
+
+    private static async ValueTask<object?> RequireAdminToken(
+        EndpointFilterInvocationContext context,
+        EndpointFilterDelegate next
+    )
+    {
+        return Authorize(context.HttpContext, AdminToken) ?? await next(context);
+    }
+
+    /// <summary>
+    /// Checks the request's bearer token against the admin token.
+    /// When no admin token is configured every request is rejected.
+    /// </summary>
+    /// <returns>A 401 result if the request is not authorized; otherwise, <c>null</c>.</returns>
+    public static IResult? Authorize(HttpContext context, string? adminToken)
+    {
+        const string bearerPrefix = "Bearer ";
+        var authorization = context.Request.Headers.Authorization.ToString();
+
+        if (
+            string.IsNullOrEmpty(adminToken)
+            || !authorization.StartsWith(bearerPrefix, StringComparison.OrdinalIgnoreCase)
+            || !CryptographicOperations.FixedTimeEquals(
+                Encoding.UTF8.GetBytes(authorization[bearerPrefix.Length..].Trim()),
+                Encoding.UTF8.GetBytes(adminToken)
+            )
+        )
+        {
+            return Results.Unauthorized();
+        }
+
+        return null;
+    }
+
+    public static IResult GetNamespaces(ISignalRegistry signalRegistry)
+    {
+        return Results.Json(signalRegistry.GetNamespaceSnapshots(), JsonConfiguration.Default);
+    }
+
+    /// <summary>
+    /// Lists every room, or with the <c>namespace</c> query parameter only that namespace's.
+    /// </summary>
+    public static IResult GetRooms(
+        ISignalRegistry signalRegistry,
+        [FromQuery(Name = "namespace")] string? signalNamespace = null
+    )
+    {
+        IReadOnlyList<RoomSnapshot> rooms = signalRegistry.GetRoomSnapshots();
+        if (signalNamespace != null)
+        {
+            rooms = rooms.Where(room => room.Namespace == signalNamespace).ToList();
+        }
+
+        return Results.Json(rooms, JsonConfiguration.Default);
+    }
+
+    public static IResult GetRoom(string hostId, ISignalRegistry signalRegistry)
+    {
+        var room = signalRegistry.GetRoomSnapshot(hostId);
+        return room == null ? Results.NotFound() : Results.Json(room, JsonConfiguration.Default);
+    }
+
+    /// <summary>
+    /// Sends the client a "kicked" close frame. The connection's receive loop then sees the
+    /// close and runs the same cleanup as any other disconnect, notifying the host.
+    /// </summary>
+    public static async Task<IResult> KickPeer(
+        string hostId,
+        string peerId,
+        [FromBody] KickRequest? request,
+        ISignalRegistry signalRegistry,
+        ILoggerFactory loggerFactory
+    )
+    {
+        if (
+            !signalRegistry.TryGetClientSocket(peerId, out var socket)
+            || !signalRegistry.TryGetClientHost(socket, out var clientHostId)
+            || clientHostId != hostId
+        )
+        {
+            return Results.NotFound();
+        }
+
+        var reason = request?.Reason;
+        var logger = loggerFactory.CreateLogger(typeof(AdminEndpoints));
+        logger.LogWarning(
+            "Kicking client {ClientId} from host {HostId}: {Reason}",
+            peerId,
+            hostId,
+            reason
+        );
+
+        var closeReason = string.IsNullOrWhiteSpace(reason)
+            ? KickedReason
+            : TruncateCloseReason($"{KickedReason}: {reason}");
+
+        await CloseConnection(socket, WebSocketCloseStatus.PolicyViolation, closeReason, logger);
+        return Results.Ok();
+    }
+
+    /// <summary>
+    /// Marks the room as migrating, so new joins are rejected, and moves its members to another
+    /// signaling endpoint in the background. Returns 202 once the migration has started.
+    /// </summary>
+    public static IResult MigrateRoom(
+        string hostId,
+        MigrateRequest request,
+        ISignalRegistry signalRegistry,
+        ILoggerFactory loggerFactory
+    )
+    {
+        if (
+            !Uri.TryCreate(request.Url, UriKind.Absolute, out var targetUri)
+            || targetUri.Scheme is not ("ws" or "wss")
+        )
+        {
+            return Results.BadRequest("url must be an absolute ws:// or wss:// URL");
+        }
+
+        if (!signalRegistry.MarkMigrating(hostId))
+        {
+            return Results.NotFound();
+        }
+
+        var logger = loggerFactory.CreateLogger(typeof(AdminEndpoints));
+        _ = MigrateRoomAsync(hostId, request.Url!, MigrationGracePeriod, signalRegistry, logger);
+
+        return Results.Accepted();
+    }
+
+    /// <summary>
+    /// Sends every member of the room a migrate message with the target URL, then closes their
+    /// connections once the grace period has passed. Clients are closed before the host so they
+    /// aren't also sent host-disconnected. Runs in the background, so failures are logged rather
+    /// than thrown.
+    /// </summary>
+    public static async Task MigrateRoomAsync(
+        string hostId,
+        string url,
+        TimeSpan gracePeriod,
+        ISignalRegistry signalRegistry,
+        ILogger logger
+    )
+    {
+        try
+        {
+            await MigrateMembersAsync(hostId, url, gracePeriod, signalRegistry, logger);
+        }
+        catch (Exception ex)
+        {
+            logger.LogError(ex, "Migration of host {HostId} failed", hostId);
+        }
+    }
+
+    private static async Task MigrateMembersAsync(
+        string hostId,
+        string url,
+        TimeSpan gracePeriod,
+        ISignalRegistry signalRegistry,
+        ILogger logger
+    )
+    {
+        logger.LogWarning("Migrating host {HostId} to {Url}", hostId, url);
+
+        var clients = signalRegistry.GetClientsForHost(hostId).ToList();
+        signalRegistry.TryGetHostSocket(hostId, out var hostSocket);
+
+        var migrate = new SignalMessage
+        {
+            Type = SignalMessageTypes.Migrate,
+            HostId = hostId,
+            Url = url,
+        };
+        var members = hostSocket == null ? clients : clients.Append(hostSocket);
+
+        await Task.WhenAll(
+            members.Select(async member =>
+            {
+                try
+                {
+                    await member.SendJsonAsync(migrate);
+                }
+                catch (Exception ex)
+                {
+                    // One unreachable member mustn't keep the rest of the room from migrating
+                    logger.LogWarning(
+                        ex,
+                        "Failed to send migrate message in room {HostId}",
+                        hostId
+                    );
+                }
+            })
+        );
+
+        await Task.Delay(gracePeriod);
+
+        await Task.WhenAll(
+            clients.Select(client =>
+                CloseConnection(
+                    client,
+                    WebSocketCloseStatus.EndpointUnavailable,
+                    MigratingReason,
+                    logger
+                )
+            )
+        );
+
+        if (hostSocket != null)
+        {
+            await CloseConnection(
+                hostSocket,
+                WebSocketCloseStatus.EndpointUnavailable,
+                MigratingReason,
+                logger
+            );
+        }
+    }
+
+    private static async Task CloseConnection(
+        WebSocket socket,
+        WebSocketCloseStatus closeStatus,
+        string reason,
+        ILogger logger
+    )
+    {
+        if (!await socket.CloseOutputOrAbortAsync(closeStatus, reason, TimeSpan.FromSeconds(5)))
+        {
+            logger.LogWarning("Could not send close frame, aborted connection");
+        }
+    }
+
+    private static string TruncateCloseReason(string reason)
+    {
+        var bytes = Encoding.UTF8.GetBytes(reason);
+        if (bytes.Length <= MaxCloseReasonBytes)
+        {
+            return reason;
+        }
+
+        // Back up to the first byte of a UTF-8 sequence so no character is cut in half
+        var length = MaxCloseReasonBytes;
+        while ((bytes[length] & 0xC0) == 0x80)
+        {
+            length--;
+        }
+        return Encoding.UTF8.GetString(bytes, 0, length);
+    }
+}
@@ -19,7 +19,7 @@ public static class ApiSpecEndpoints
                         connection = new
                         {
                             url = "ws://localhost:5052/ws",
-                            description = "WebSocket connection endpoint",
+                            description = "WebSocket connection endpoint; /ws/{namespace} isolates rooms per app",
                         },
                         messageFormat = new
                         {
@@ -45,6 +45,11 @@ public static class ApiSpecEndpoints
                                         type = "string",
                                         description = "Message payload",
                                     },
+                                    joinSecret = new
+                                    {
+                                        type = "string",
+                                        description = "Optional room password",
+                                    },
                                 },
                                 required = new[] { "type" },
                             },
@@ -68,6 +73,7 @@ public static class ApiSpecEndpoints
                                     type = "join-host",
                                     hostId = "target-host-id",
                                     clientId = "generated-client-id",
+                                    shouldOffer = false,
                                 },
                             },
                             clientJoined = new
@@ -79,6 +85,7 @@ public static class ApiSpecEndpoints
                                     type = "client-joined",
                                     hostId = "abc123",
                                     clientId = "xyz456",
+                                    shouldOffer = true,
                                 },
                             },
                             msgToHost = new
@@ -100,6 +107,51 @@ public static class ApiSpecEndpoints
                                 },
                                 response = "No direct response - message forwarded to client",
                             },
+                            ack = new
+                            {
+                                description = "Server confirms a relayed message with a requestId was queued to its target",
+                                direction = "server_to_client",
+                                response = new { type = "ack", requestId = "req-1" },
+                            },
+                            nack = new
+                            {
+                                description = "Server reports a relayed message with a requestId was not delivered",
+                                direction = "server_to_client",
+                                response = new
+                                {
+                                    type = "nack",
+                                    requestId = "req-1",
+                                    reason = "peer-unavailable",
+                                },
+                            },
+                            roomState = new
+                            {
+                                description = "Server tells a newly joined client which clients are already in the room",
+                                direction = "server_to_client",
+                                response = new
+                                {
+                                    type = "room-state",
+                                    hostId = "abc123",
+                                    clientIds = new[] { "def789" },
+                                },
+                            },
+                            setMetadata = new
+                            {
+                                description = "Client replaces its metadata; the rest of the room receives peer-updated",
+                                direction = "client_to_server",
+                                request = new
+                                {
+                                    type = "set-metadata",
+                                    metadata = new { name = "Ada" },
+                                },
+                                response = new
+                                {
+                                    type = "peer-updated",
+                                    hostId = "abc123",
+                                    clientId = "xyz456",
+                                    metadata = new { name = "Ada" },
+                                },
+                            },
                             clientDisconnected = new
                             {
                                 description = "Server notifies host that a client disconnected",
@@ -118,7 +170,30 @@ public static class ApiSpecEndpoints
                                 response = new { type = "host-disconnected", hostId = "abc123" },
                             },
                         },
-                        errorFormat = new { type = "error", message = "Error description" },
+                        errorFormat = new
+                        {
+                            type = "error",
+                            code = "peer-unavailable",
+                            message = "Error description",
+                            detail = "Optional specifics, e.g. validation errors",
+                            requestId = "Echoed from the failed request, if it had one",
+                        },
+                        errorCodes = new[]
+                        {
+                            "bad-message",
+                            "unknown-type",
+                            "not-registered",
+                            "invalid-room-id",
+                            "host-not-found",
+                            "peer-unavailable",
+                            "room-full",
+                            "forbidden",
+                            "bad-secret",
+                            "migrating",
+                            "metadata-too-large",
+                            "type-not-allowed",
+                            "rate-limited",
+                        },
                         examples = new
                         {
                             registerHost = new
@@ -1,3 +1,5 @@
+using SignalingServer.Services;
+
 namespace SignalingServer.Endpoints;
 
 public static class HealthEndpoints
@@ -6,10 +8,23 @@ public static class HealthEndpoints
     {
         app.MapGet("/health", GetHealth)
             .RequireCors(policy => policy.AllowAnyOrigin().AllowAnyHeader().AllowAnyMethod());
+
+        // Liveness: the process is up
+        app.MapGet("/livez", GetHealth);
+
+        // Readiness: the instance accepts new connections
+        app.MapGet("/readyz", GetReadiness);
     }
 
     private static string GetHealth()
     {
         return "OK";
     }
+
+    private static IResult GetReadiness(ReadinessState readinessState)
+    {
+        return readinessState.IsReady
+            ? Results.Text("OK")
+            : Results.Text("Not ready", statusCode: StatusCodes.Status503ServiceUnavailable);
+    }
 }
@@ -0,0 +1,192 @@
+using System.Net.WebSockets;
+using System.Security.Claims;
+using System.Text;
+using SignalingServer.Configuration;
+using SignalingServer.Extensions;
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Validation;
+
+namespace SignalingServer.Endpoints;
+
+/// <summary>
+/// HTTP long-poll fallback for networks that block WebSockets. A peer opens a session, then
+/// POSTs signal messages and GETs the ones sent to it, identifying itself with the session id.
+/// Messages use the same JSON format and routing as the WebSocket endpoint.
+/// </summary>
+public static class PollEndpoints
+{
+    private const string PollPath = "/signal/poll";
+
+    public const string SessionHeader = "X-Poll-Session";
+
+    public static IEndpointRouteBuilder MapPollEndpoints(this IEndpointRouteBuilder app)
+    {
+        app.MapPost(PollPath + "/session", OpenSession)
+            .RequireRateLimiting(ConnectionRateLimiting.PolicyName);
+        // Requests within a session aren't connection attempts: the messages they carry are
+        // limited per session by the connection handler, like WebSocket frames
+        app.MapPost(PollPath, Send);
+        app.MapGet(PollPath, Receive);
+        app.MapDelete(PollPath, CloseSession);
+
+        return app;
+    }
+
+    /// <summary>
+    /// Opens a session after the same origin, token and capacity checks as a WebSocket upgrade.
+    /// The optional <c>namespace</c> query parameter plays the part of the <c>/ws/{namespace}</c>
+    /// path segment.
+    /// </summary>
+    public static async Task<IResult> OpenSession(
+        HttpContext context,
+        LongPollSessions sessions,
+        ConnectionLimiter connectionLimiter,
+        OriginValidator originValidator,
+        JwtAuthenticator authenticator,
+        ISignalRegistry signalRegistry,
+        RoomIdValidator roomIdValidator
+    )
+    {
+        if (!originValidator.IsOriginAllowed(context))
+            return Results.Text("Origin not allowed", statusCode: StatusCodes.Status403Forbidden);
+
+        var requestedNamespace = context.Request.Query["namespace"].ToString();
+        if (
+            !WebSocketEndpoints.TryResolveNamespace(
+                requestedNamespace,
+                roomIdValidator,
+                out var signalNamespace
+            )
+        )
+        {
+            return Results.Text("Invalid namespace", statusCode: StatusCodes.Status400BadRequest);
+        }
+
+        ClaimsIdentity? identity = null;
+        if (authenticator.IsEnabled)
+        {
+            identity = await authenticator.AuthenticateAsync(
+                WebSocketEndpoints.GetBearerToken(context),
+                context.RequestAborted
+            );
+            if (identity == null)
+                return Results.Text("Unauthorized", statusCode: StatusCodes.Status401Unauthorized);
+        }
+
+        if (!WebSocketEndpoints.TryAcquireConnectionSlot(context, connectionLimiter))
+        {
+            return Results.Text(
+                "Too many connections",
+                statusCode: StatusCodes.Status503ServiceUnavailable
+            );
+        }
+
+        // No message can arrive before the session id is returned, so the namespace and claims
+        // applied after the session starts are in place for its first message
+        var socket = sessions.Open(context.GetTraceContext());
+        signalRegistry.SetNamespace(socket, signalNamespace);
+        if (identity != null)
+        {
+            WebSocketEndpoints.ApplyTokenClaims(signalRegistry, socket, identity);
+        }
+
+        var session = new PollSession(
+            socket.SessionId,
+            (int)sessions.Options.PollTimeout.TotalMilliseconds
+        );
+        return Results.Json(
+            session,
+            JsonConfiguration.Default,
+            statusCode: StatusCodes.Status201Created
+        );
+    }
+
+    /// <summary>
+    /// Hands one signal message, the raw request body, to the session's receive loop. Bodies
+    /// over the maximum message size are refused with 413, as the WebSocket transport would
+    /// refuse the frame.
+    /// </summary>
+    public static async Task<IResult> Send(HttpContext context, LongPollSessions sessions)
+    {
+        if (!sessions.TryGet(GetSessionId(context), out var socket))
+            return Results.NotFound();
+
+        if (socket.State != WebSocketState.Open)
+            return Results.StatusCode(StatusCodes.Status410Gone);
+
+        var maxMessageSize = sessions.Options.MaxMessageSize;
+        if (context.Request.ContentLength > maxMessageSize)
+            return Results.StatusCode(StatusCodes.Status413PayloadTooLarge);
+
+        var raw = await ReadBodyAsync(context.Request, maxMessageSize, context.RequestAborted);
+        if (raw == null)
+            return Results.StatusCode(StatusCodes.Status413PayloadTooLarge);
+
+        if (string.IsNullOrWhiteSpace(raw))
+            return Results.BadRequest();
+
+        return socket.TryEnqueue(raw)
+            ? Results.Accepted()
+            : Results.StatusCode(StatusCodes.Status429TooManyRequests);
+    }
+
+    /// <summary>
+    /// Waits up to the poll timeout for messages sent to the session and returns them as a JSON
+    /// array, empty if none arrived. Once the session has ended and everything queued for it
+    /// has been collected, answers 410 and forgets the session.
+    /// </summary>
+    public static async Task<IResult> Receive(HttpContext context, LongPollSessions sessions)
+    {
+        if (!sessions.TryGet(GetSessionId(context), out var socket))
+            return Results.NotFound();
+
+        var messages = await socket.PollAsync(sessions.Options.PollTimeout, context.RequestAborted);
+        if (messages.Count == 0 && socket.IsDrained)
+        {
+            sessions.Remove(socket.SessionId);
+            return Results.StatusCode(StatusCodes.Status410Gone);
+        }
+
+        // Each message is already serialized JSON
+        return Results.Text("[" + string.Join(",", messages) + "]", "application/json");
+    }
+
+    /// <summary>
+    /// Leaves the session, like closing a WebSocket: the peer's room is told it disconnected.
+    /// </summary>
+    public static IResult CloseSession(HttpContext context, LongPollSessions sessions)
+    {
+        if (!sessions.TryGet(GetSessionId(context), out var socket))
+            return Results.NotFound();
+
+        socket.Disconnect();
+        sessions.Remove(socket.SessionId);
+        return Results.NoContent();
+    }
+
+    // Reads the body as UTF-8, giving up with null once it passes maxBytes. Content-Length can
+    // be absent with chunked uploads, so the limit is enforced on what is actually read.
+    private static async Task<string?> ReadBodyAsync(
+        HttpRequest request,
+        int maxBytes,
+        CancellationToken cancellationToken
+    )
+    {
+        using var body = new MemoryStream();
+        var chunk = new byte[4096];
+        int read;
+        while ((read = await request.Body.ReadAsync(chunk, cancellationToken)) > 0)
+        {
+            if (body.Length + read > maxBytes)
+                return null;
+
+            body.Write(chunk, 0, read);
+        }
+
+        return Encoding.UTF8.GetString(body.GetBuffer(), 0, (int)body.Length);
+    }
+
+    private static string? GetSessionId(HttpContext context) =>
+        context.Request.Headers[SessionHeader].FirstOrDefault();
+}
@@ -0,0 +1,50 @@
+using SignalingServer.Configuration;
+using SignalingServer.Services;
+
+namespace SignalingServer.Endpoints;
+
+public static class TurnEndpoints
+{
+    public static void MapTurnEndpoints(this WebApplication app)
+    {
+        app.MapGet("/turn-credentials", GetTurnCredentials)
+            .RequireRateLimiting(ConnectionRateLimiting.PolicyName);
+    }
+
+    /// <summary>
+    /// Issues TURN credentials for the peer. When bearer tokens are required for signaling they
+    /// are required here too, so the relay can't be used by anyone who can reach the server.
+    /// </summary>
+    public static async Task<IResult> GetTurnCredentials(
+        HttpContext context,
+        string? peerId,
+        TurnCredentialGenerator generator,
+        JwtAuthenticator authenticator
+    )
+    {
+        if (authenticator.IsEnabled)
+        {
+            var identity = await authenticator.AuthenticateAsync(
+                WebSocketEndpoints.GetBearerToken(context),
+                context.RequestAborted
+            );
+            if (identity == null)
+                return Results.Text("Unauthorized", statusCode: StatusCodes.Status401Unauthorized);
+        }
+
+        if (!generator.IsConfigured)
+        {
+            return Results.Text(
+                "TURN is not configured",
+                statusCode: StatusCodes.Status503ServiceUnavailable
+            );
+        }
+
+        if (string.IsNullOrWhiteSpace(peerId))
+        {
+            return Results.Text("Missing peerId", statusCode: StatusCodes.Status400BadRequest);
+        }
+
+        return Results.Json(generator.Generate(peerId), JsonConfiguration.Default);
+    }
+}
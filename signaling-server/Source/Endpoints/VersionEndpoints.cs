@@ -0,0 +1,13 @@
+using SignalingServer.Configuration;
+
+namespace SignalingServer.Endpoints;
+
+public static class VersionEndpoints
+{
+    public static void MapVersionEndpoints(this WebApplication app)
+    {
+        app.MapGet("/version", GetVersion);
+    }
+
+    public static IResult GetVersion() => Results.Json(BuildInfo.Current);
+}
@@ -1,4 +1,11 @@
+using System.Diagnostics.CodeAnalysis;
+using System.Net.WebSockets;
+using System.Security.Claims;
 using Microsoft.Extensions.Options;
+using SignalingServer.Configuration;
+using SignalingServer.Extensions;
+using SignalingServer.Helpers;
+using SignalingServer.Models;
 using SignalingServer.Services;
 using SignalingServer.Validation;
 
@@ -8,44 +15,230 @@ public static class WebSocketEndpoints
 {
     private const string WebSocketPath = "/ws";
 
+    private static readonly int OutboundBufferSize = int.Parse(
+        Environment.GetEnvironmentVariable("OUTBOUND_BUFFER_SIZE") ?? "256"
+    ); // Messages queued per connection before it is dropped as a slow consumer
+
+    // Offer permessage-deflate to clients that support it; WS_COMPRESSION=false saves the CPU
+    private static readonly bool CompressionEnabled = bool.Parse(
+        Environment.GetEnvironmentVariable("WS_COMPRESSION") ?? "true"
+    );
+
+    // Seconds a client rejected at the connection ceiling should wait before retrying
+    private const int RetryAfterSeconds = 10;
+
     public static IEndpointRouteBuilder MapWebSocketEndpoints(this IEndpointRouteBuilder app)
     {
-        app.Map(
-            WebSocketPath,
-            async context =>
+        app.Map(WebSocketPath, HandleWebSocketRequest)
+            .RequireRateLimiting(ConnectionRateLimiting.PolicyName);
+        app.Map(WebSocketPath + "/{namespace}", HandleWebSocketRequest)
+            .RequireRateLimiting(ConnectionRateLimiting.PolicyName);
+
+        return app;
+    }
+
+    private static async Task HandleWebSocketRequest(HttpContext context)
+    {
+        if (context.WebSockets.IsWebSocketRequest)
+        {
+            var originValidator = context.RequestServices.GetRequiredService<OriginValidator>();
+
+            if (!originValidator.IsOriginAllowed(context))
             {
-                if (context.WebSockets.IsWebSocketRequest)
-                {
-                    var originValidator =
-                        context.RequestServices.GetRequiredService<OriginValidator>();
-
-                    if (!originValidator.IsOriginAllowed(context))
-                    {
-                        context.Response.StatusCode = 403;
-                        await context.Response.WriteAsync("Origin not allowed");
-                        return;
-                    }
-
-                    var webSocketOptions = context.RequestServices.GetRequiredService<
-                        IOptions<WebSocketOptions>
-                    >();
-                    var acceptContext = new WebSocketAcceptContext
-                    {
-                        KeepAliveInterval = webSocketOptions.Value.KeepAliveInterval,
-                    };
-                    var webSocket = await context.WebSockets.AcceptWebSocketAsync(acceptContext);
-
-                    var connectionHandler =
-                        context.RequestServices.GetRequiredService<IConnectionHandler>();
-                    await connectionHandler.HandleConnection(webSocket, context.RequestAborted);
-                }
-                else
+                context.Response.StatusCode = 403;
+                await context.Response.WriteAsync("Origin not allowed");
+                return;
+            }
+
+            var roomIdValidator = context.RequestServices.GetRequiredService<RoomIdValidator>();
+            var requestedNamespace = context.Request.RouteValues["namespace"] as string;
+            if (!TryResolveNamespace(requestedNamespace, roomIdValidator, out var signalNamespace))
+            {
+                context.Response.StatusCode = 400;
+                await context.Response.WriteAsync("Invalid namespace");
+                return;
+            }
+
+            var authenticator = context.RequestServices.GetRequiredService<JwtAuthenticator>();
+            ClaimsIdentity? identity = null;
+
+            if (authenticator.IsEnabled)
+            {
+                identity = await authenticator.AuthenticateAsync(
+                    GetBearerToken(context),
+                    context.RequestAborted
+                );
+                if (identity == null)
                 {
-                    context.Response.StatusCode = 400;
+                    context.Response.StatusCode = 401;
+                    await context.Response.WriteAsync("Unauthorized");
+                    return;
                 }
             }
+
+            if (
+                !SignalSubprotocols.TryNegotiate(
+                    context.WebSockets.WebSocketRequestedProtocols,
+                    SignalSubprotocols.Supported,
+                    out var subProtocol
+                )
+            )
+            {
+                context.Response.StatusCode = 400;
+                await context.Response.WriteAsync("Unsupported subprotocol");
+                return;
+            }
+
+            var connectionLimiter = context.RequestServices.GetRequiredService<ConnectionLimiter>();
+            if (!TryAcquireConnectionSlot(context, connectionLimiter))
+            {
+                await context.Response.WriteAsync("Too many connections");
+                return;
+            }
+
+            try
+            {
+                await AcceptConnection(context, subProtocol, identity, signalNamespace);
+            }
+            finally
+            {
+                // Runs however the connection ends, including aborts and handler exceptions
+                connectionLimiter.Release();
+            }
+        }
+        else
+        {
+            context.Response.StatusCode = 400;
+        }
+    }
+
+    /// <summary>
+    /// Takes a slot from the connection limiter, or answers the upgrade with 503 and a
+    /// Retry-After header when the server is at its connection ceiling.
+    /// </summary>
+    public static bool TryAcquireConnectionSlot(HttpContext context, ConnectionLimiter limiter)
+    {
+        if (limiter.TryAcquire())
+            return true;
+
+        context.Response.StatusCode = StatusCodes.Status503ServiceUnavailable;
+        context.Response.Headers.RetryAfter = RetryAfterSeconds.ToString();
+        return false;
+    }
+
+    private static async Task AcceptConnection(
+        HttpContext context,
+        string? subProtocol,
+        ClaimsIdentity? identity,
+        string signalNamespace
+    )
+    {
+        var webSocketOptions = context.RequestServices.GetRequiredService<
+            IOptions<WebSocketOptions>
+        >();
+        var acceptContext = CreateAcceptContext(
+            webSocketOptions.Value,
+            subProtocol,
+            CompressionEnabled
+        );
+        var webSocket = new BufferedWebSocket(
+            await context.WebSockets.AcceptWebSocketAsync(acceptContext),
+            OutboundBufferSize
         );
 
-        return app;
+        var signalRegistry = context.RequestServices.GetRequiredService<ISignalRegistry>();
+        signalRegistry.SetNamespace(webSocket, signalNamespace);
+        if (identity != null)
+        {
+            ApplyTokenClaims(signalRegistry, webSocket, identity);
+        }
+
+        var connectionHandler =
+            context.RequestServices.GetRequiredService<IConnectionHandler>();
+        await connectionHandler.HandleConnection(
+            webSocket,
+            context.RequestAborted,
+            context.GetTraceContext()
+        );
+    }
+
+    /// <summary>
+    /// Builds the settings an upgrade is accepted with. When compression is enabled,
+    /// permessage-deflate is negotiated with clients that offer it; other clients get an
+    /// uncompressed connection either way.
+    /// </summary>
+    public static WebSocketAcceptContext CreateAcceptContext(
+        WebSocketOptions options,
+        string? subProtocol,
+        bool enableCompression
+    ) =>
+        new()
+        {
+            KeepAliveInterval = options.KeepAliveInterval,
+            KeepAliveTimeout = options.KeepAliveTimeout,
+            SubProtocol = subProtocol,
+            DangerousEnableCompression = enableCompression,
+            // Each message is compressed on its own. Sharing the window across messages would
+            // let a peer probe secrets in server messages, like reconnect tokens, by size.
+            DisableServerContextTakeover = true,
+        };
+
+    /// <summary>
+    /// Resolves the namespace a connection asked for, the default one if it gave none.
+    /// Requested names follow the same rules as room ids; the default is always accepted, even
+    /// under a ROOM_ID_PATTERN it doesn't match.
+    /// </summary>
+    public static bool TryResolveNamespace(
+        string? requested,
+        RoomIdValidator validator,
+        [NotNullWhen(true)] out string? signalNamespace
+    )
+    {
+        if (string.IsNullOrEmpty(requested))
+        {
+            signalNamespace = SignalNamespaces.Default;
+            return true;
+        }
+
+        signalNamespace = requested;
+        if (validator.IsValid(signalNamespace))
+            return true;
+
+        signalNamespace = null;
+        return false;
+    }
+
+    /// <summary>
+    /// Restricts the connection to the hosts and message types its token grants.
+    /// </summary>
+    public static void ApplyTokenClaims(
+        ISignalRegistry signalRegistry,
+        WebSocket socket,
+        ClaimsIdentity identity
+    )
+    {
+        if (JwtAuthenticator.GetAllowedHosts(identity) is { } allowedHosts)
+        {
+            signalRegistry.SetAllowedHosts(socket, allowedHosts);
+        }
+
+        if (JwtAuthenticator.GetAllowedActions(identity) is { } allowedActions)
+        {
+            signalRegistry.SetAllowedActions(socket, allowedActions);
+        }
+    }
+
+    // Browsers can't set headers on a WebSocket upgrade, so the token may also be passed
+    // as the access_token query parameter
+    public static string? GetBearerToken(HttpContext context)
+    {
+        var authorization = context.Request.Headers.Authorization.ToString();
+        if (authorization.StartsWith("Bearer ", StringComparison.OrdinalIgnoreCase))
+        {
+            return authorization["Bearer ".Length..].Trim();
+        }
+
+        var accessToken = context.Request.Query["access_token"].ToString();
+        return string.IsNullOrEmpty(accessToken) ? null : accessToken;
     }
 }
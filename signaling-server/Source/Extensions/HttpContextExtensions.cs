@@ -0,0 +1,66 @@
+using System.Diagnostics;
+
+namespace SignalingServer.Extensions;
+
+/// <summary>
+/// Extension methods for working with <see cref="HttpContext"/>.
+/// </summary>
+public static class HttpContextExtensions
+{
+    /// <summary>
+    /// Resolves the IP address of the client that made the request.
+    /// </summary>
+    /// <param name="context">The current HTTP context.</param>
+    /// <param name="trustForwardedHeaders">
+    /// Whether to honor <c>X-Forwarded-For</c>. Only enable this when the server runs behind a
+    /// proxy that sets the header, otherwise clients can spoof it.
+    /// </param>
+    /// <param name="trustedProxyHops">
+    /// How many proxies in front of the server append to <c>X-Forwarded-For</c>. The client is
+    /// the entry that many places from the right; entries further left were sent by the client
+    /// and can't be trusted.
+    /// </param>
+    /// <returns>The client IP address, or "unknown" if it cannot be determined.</returns>
+    public static string GetClientIp(
+        this HttpContext context,
+        bool trustForwardedHeaders,
+        int trustedProxyHops = 1
+    )
+    {
+        if (trustForwardedHeaders && trustedProxyHops > 0)
+        {
+            var entries = context
+                .Request.Headers["X-Forwarded-For"]
+                .SelectMany(value => (value ?? "").Split(','))
+                .Select(entry => entry.Trim())
+                .ToArray();
+            if (entries.Length >= trustedProxyHops)
+            {
+                var clientIp = entries[^trustedProxyHops];
+                if (!string.IsNullOrEmpty(clientIp))
+                    return clientIp;
+            }
+        }
+
+        return context.Connection.RemoteIpAddress?.ToString() ?? "unknown";
+    }
+
+    /// <summary>
+    /// Resolves the trace context the request continues: that of the request's own activity if
+    /// ASP.NET Core created one, otherwise the W3C <c>traceparent</c> header, if present.
+    /// </summary>
+    /// <param name="context">The current HTTP context.</param>
+    /// <returns>The parent trace context, or default if the request carries none.</returns>
+    public static ActivityContext GetTraceContext(this HttpContext context)
+    {
+        if (Activity.Current is { } activity)
+            return activity.Context;
+
+        ActivityContext.TryParse(
+            context.Request.Headers.TraceParent,
+            context.Request.Headers.TraceState,
+            out var parentContext
+        );
+        return parentContext;
+    }
+}
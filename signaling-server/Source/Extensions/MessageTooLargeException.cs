@@ -0,0 +1,10 @@
+namespace SignalingServer.Extensions;
+
+/// <summary>
+/// Thrown when an incoming WebSocket message exceeds the configured maximum size.
+/// </summary>
+public class MessageTooLargeException(int maxSizeInBytes)
+    : Exception($"Message exceeds the maximum size of {maxSizeInBytes} bytes")
+{
+    public int MaxSizeInBytes { get; } = maxSizeInBytes;
+}
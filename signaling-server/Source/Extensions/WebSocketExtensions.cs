@@ -29,6 +29,24 @@ public static class WebSocketExtensions
         );
     }
 
+    /// <summary>
+    /// Sends raw bytes over the WebSocket as a single binary message.
+    /// </summary>
+    /// <param name="socket">The target WebSocket.</param>
+    /// <param name="data">The bytes to send.</param>
+    public static async Task SendBinaryAsync(this WebSocket socket, byte[] data)
+    {
+        if (socket.State != WebSocketState.Open)
+            return;
+
+        await socket.SendAsync(
+            new ArraySegment<byte>(data),
+            WebSocketMessageType.Binary,
+            endOfMessage: true,
+            cancellationToken: CancellationToken.None
+        );
+    }
+
     /// <summary>
     /// Serializes an object to JSON and sends it over the WebSocket using centralized configuration.
     /// </summary>
@@ -56,14 +74,60 @@ public static class WebSocketExtensions
     /// Sends a standardized error response over the WebSocket and logs it if a logger is provided.
     /// </summary>
     /// <param name="socket">The target WebSocket.</param>
-    /// <param name="errorMessage">The error message to send.</param>
-    public static async Task SendErrorAsync(this WebSocket socket, string errorMessage)
+    /// <param name="errorMessage">The human-readable error message to send.</param>
+    /// <param name="code">Machine-readable error code, see <see cref="SignalErrorCodes"/>.</param>
+    /// <param name="requestId">Optional id of the request that failed, echoed back to the sender.</param>
+    /// <param name="detail">Optional specifics for the failure.</param>
+    public static async Task SendErrorAsync(
+        this WebSocket socket,
+        string errorMessage,
+        string code,
+        string? requestId = null,
+        string? detail = null
+    )
     {
-        var error = new SignalErrorResponse { Type = "error", Message = errorMessage };
+        var error = new SignalErrorResponse
+        {
+            Type = SignalMessageTypes.Error,
+            Code = code,
+            Message = errorMessage,
+            RequestId = requestId,
+            Detail = detail,
+        };
 
         await socket.SendJsonAsync(error);
     }
 
+    /// <summary>
+    /// Sends a close frame without waiting for the peer's reply. The connection's receive loop
+    /// then sees the close and runs the normal disconnect cleanup. If the frame can't be sent
+    /// within the timeout the socket is aborted, which fails the receive and takes the same path.
+    /// </summary>
+    /// <param name="socket">The WebSocket to close.</param>
+    /// <param name="closeStatus">The close status to send.</param>
+    /// <param name="reason">The close reason, at most 123 bytes of UTF-8.</param>
+    /// <param name="timeout">How long to wait for the close frame to be sent.</param>
+    /// <returns><c>true</c> if the close frame was sent; <c>false</c> if the socket was aborted.</returns>
+    public static async Task<bool> CloseOutputOrAbortAsync(
+        this WebSocket socket,
+        WebSocketCloseStatus closeStatus,
+        string reason,
+        TimeSpan timeout
+    )
+    {
+        using var cts = new CancellationTokenSource(timeout);
+        try
+        {
+            await socket.CloseOutputAsync(closeStatus, reason, cts.Token);
+            return true;
+        }
+        catch (Exception ex) when (ex is WebSocketException or OperationCanceledException)
+        {
+            socket.Abort();
+            return false;
+        }
+    }
+
     /// <summary>
     /// Receives a complete WebSocket message, handling fragmentation and size limits.
     /// </summary>
@@ -72,13 +136,38 @@ public static class WebSocketExtensions
     /// <param name="chunkSize">Buffer size for each read operation. Default is 4096 bytes.</param>
     /// <param name="cancellationToken">Token for cancellation.</param>
     /// <returns>The full UTF-8 message string, or null if the socket is closed.</returns>
-    /// <exception cref="WebSocketException">Thrown if the message exceeds the maximum size.</exception>
+    /// <exception cref="MessageTooLargeException">Thrown if the message exceeds the maximum size.</exception>
     public static async Task<string?> ReceiveFullMessageAsync(
         this WebSocket socket,
         int maxSizeInBytes = 64 * 1024,
         int chunkSize = 4 * 1024,
         CancellationToken cancellationToken = default
     )
+    {
+        var message = await socket.ReceiveMessageAsync(
+            maxSizeInBytes,
+            chunkSize,
+            cancellationToken
+        );
+
+        return message == null ? null : Encoding.UTF8.GetString(message.Data);
+    }
+
+    /// <summary>
+    /// Receives a complete WebSocket message of either type, handling fragmentation and size limits.
+    /// </summary>
+    /// <param name="socket">The source WebSocket.</param>
+    /// <param name="maxSizeInBytes">Maximum allowed message size in bytes. Default is 64 KB.</param>
+    /// <param name="chunkSize">Buffer size for each read operation. Default is 4096 bytes.</param>
+    /// <param name="cancellationToken">Token for cancellation.</param>
+    /// <returns>The message type and bytes, or null if the socket is closed.</returns>
+    /// <exception cref="MessageTooLargeException">Thrown if the message exceeds the maximum size.</exception>
+    public static async Task<ReceivedMessage?> ReceiveMessageAsync(
+        this WebSocket socket,
+        int maxSizeInBytes = 64 * 1024,
+        int chunkSize = 4 * 1024,
+        CancellationToken cancellationToken = default
+    )
     {
         var buffer = new byte[chunkSize];
         using var ms = new MemoryStream();
@@ -96,12 +185,10 @@ public static class WebSocketExtensions
             ms.Write(buffer, 0, result.Count);
 
             if (ms.Length > maxSizeInBytes)
-                throw new WebSocketException("Message too large");
+                throw new MessageTooLargeException(maxSizeInBytes);
 
             if (result.EndOfMessage)
-                break;
+                return new ReceivedMessage(result.MessageType, ms.ToArray());
         }
-
-        return Encoding.UTF8.GetString(ms.ToArray());
     }
 }
@@ -0,0 +1,52 @@
+using System.Diagnostics.CodeAnalysis;
+using System.Text;
+
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// Framing for binary messages relayed between peers. A frame starts with one byte holding
+/// the length of the peer id, followed by the id in ASCII; the remaining bytes are an opaque
+/// payload. Inbound frames name the target peer, relayed frames name the sender.
+/// </summary>
+public static class BinaryEnvelope
+{
+    /// <summary>
+    /// Splits a frame into its peer id and payload.
+    /// </summary>
+    /// <returns><c>true</c> if the frame has a non-empty peer id header; otherwise, <c>false</c>.</returns>
+    public static bool TryParse(
+        byte[] frame,
+        [NotNullWhen(true)] out string? peerId,
+        out ReadOnlyMemory<byte> payload
+    )
+    {
+        peerId = null;
+        payload = ReadOnlyMemory<byte>.Empty;
+
+        if (frame.Length == 0)
+            return false;
+
+        var idLength = frame[0];
+        if (idLength == 0 || frame.Length < 1 + idLength)
+            return false;
+
+        peerId = Encoding.ASCII.GetString(frame, 1, idLength);
+        payload = frame.AsMemory(1 + idLength);
+        return true;
+    }
+
+    /// <summary>
+    /// Builds a frame with the given peer id header followed by the payload bytes.
+    /// </summary>
+    public static byte[] Create(string peerId, ReadOnlySpan<byte> payload)
+    {
+        var id = Encoding.ASCII.GetBytes(peerId);
+        var frame = new byte[1 + id.Length + payload.Length];
+
+        frame[0] = (byte)id.Length;
+        id.CopyTo(frame, 1);
+        payload.CopyTo(frame.AsSpan(1 + id.Length));
+
+        return frame;
+    }
+}
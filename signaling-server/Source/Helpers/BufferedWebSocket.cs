@@ -0,0 +1,177 @@
+using System.Net.WebSockets;
+using System.Threading.Channels;
+using SignalingServer.Services;
+
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// Wraps a WebSocket so that sends are queued in a bounded outbound buffer and written by a
+/// dedicated writer task. A peer that stops reading can therefore never block the connection
+/// that is sending to it; once its buffer is full it is closed as a slow consumer instead.
+/// </summary>
+public class BufferedWebSocket : WebSocket, IDroppableWebSocket
+{
+    public const string SlowConsumerReason = "slow-consumer";
+
+    private static readonly TimeSpan CloseTimeout = TimeSpan.FromSeconds(1);
+
+    private readonly WebSocket _inner;
+    private readonly Channel<OutboundFrame> _outbound;
+    private readonly Task _writer;
+    private int _dropped;
+    private int _closing;
+
+    /// <param name="inner">The accepted WebSocket to write to.</param>
+    /// <param name="capacity">Maximum number of queued, unsent messages.</param>
+    public BufferedWebSocket(WebSocket inner, int capacity)
+    {
+        _inner = inner;
+        _outbound = Channel.CreateBounded<OutboundFrame>(
+            new BoundedChannelOptions(capacity)
+            {
+                SingleReader = true,
+                FullMode = BoundedChannelFullMode.Wait,
+            }
+        );
+        _writer = Task.Run(WriteLoopAsync);
+    }
+
+    /// <summary>
+    /// Whether this socket was closed because its outbound buffer overflowed.
+    /// </summary>
+    public bool IsDropped => Volatile.Read(ref _dropped) == 1;
+
+    public override WebSocketCloseStatus? CloseStatus => _inner.CloseStatus;
+    public override string? CloseStatusDescription => _inner.CloseStatusDescription;
+    public override WebSocketState State => _inner.State;
+    public override string? SubProtocol => _inner.SubProtocol;
+
+    /// <summary>
+    /// Queues the frame and returns immediately. If the buffer is full the socket is dropped
+    /// and the frame discarded, so callers never wait on a slow peer. Frames sent once the
+    /// socket is closing are discarded.
+    /// </summary>
+    public override Task SendAsync(
+        ArraySegment<byte> buffer,
+        WebSocketMessageType messageType,
+        bool endOfMessage,
+        CancellationToken cancellationToken
+    )
+    {
+        var frame = new OutboundFrame(buffer.ToArray(), messageType, endOfMessage);
+        // The flag is set before the channel is completed, so a write that loses the race
+        // with a close is never mistaken for a full buffer
+        if (!_outbound.Writer.TryWrite(frame) && Volatile.Read(ref _closing) == 0)
+        {
+            DropSlowConsumer();
+        }
+
+        return Task.CompletedTask;
+    }
+
+    public override Task<WebSocketReceiveResult> ReceiveAsync(
+        ArraySegment<byte> buffer,
+        CancellationToken cancellationToken
+    ) => _inner.ReceiveAsync(buffer, cancellationToken);
+
+    public override async Task CloseAsync(
+        WebSocketCloseStatus closeStatus,
+        string? statusDescription,
+        CancellationToken cancellationToken
+    )
+    {
+        await FlushAsync();
+        await _inner.CloseAsync(closeStatus, statusDescription, cancellationToken);
+    }
+
+    public override async Task CloseOutputAsync(
+        WebSocketCloseStatus closeStatus,
+        string? statusDescription,
+        CancellationToken cancellationToken
+    )
+    {
+        await FlushAsync();
+        await _inner.CloseOutputAsync(closeStatus, statusDescription, cancellationToken);
+    }
+
+    public override void Abort()
+    {
+        CompleteOutbound();
+        _inner.Abort();
+    }
+
+    public override void Dispose()
+    {
+        CompleteOutbound();
+        _inner.Dispose();
+    }
+
+    // Lets queued messages go out before the close frame, without waiting on a stalled peer
+    private async Task FlushAsync()
+    {
+        CompleteOutbound();
+        await Task.WhenAny(_writer, Task.Delay(CloseTimeout));
+    }
+
+    private void CompleteOutbound()
+    {
+        Volatile.Write(ref _closing, 1);
+        _outbound.Writer.TryComplete();
+    }
+
+    private async Task WriteLoopAsync()
+    {
+        try
+        {
+            await foreach (var frame in _outbound.Reader.ReadAllAsync())
+            {
+                await _inner.SendAsync(
+                    frame.Buffer,
+                    frame.MessageType,
+                    frame.EndOfMessage,
+                    CancellationToken.None
+                );
+            }
+        }
+        catch (Exception ex) when (ex is WebSocketException or ObjectDisposedException)
+        {
+            // The connection is gone; its receive loop runs the disconnect cleanup
+            CompleteOutbound();
+        }
+    }
+
+    private void DropSlowConsumer()
+    {
+        if (Interlocked.Exchange(ref _dropped, 1) == 1)
+            return;
+
+        _outbound.Writer.TryComplete();
+        SignalingMetrics.DroppedSlowConsumers.Inc();
+        _ = CloseSlowConsumerAsync();
+    }
+
+    private async Task CloseSlowConsumerAsync()
+    {
+        // The writer is likely stuck mid-send, so the close frame may never get out;
+        // abort the connection if it can't be sent in time
+        using var timeout = new CancellationTokenSource(CloseTimeout);
+        try
+        {
+            await _inner.CloseOutputAsync(
+                WebSocketCloseStatus.PolicyViolation,
+                SlowConsumerReason,
+                timeout.Token
+            );
+        }
+        catch (Exception ex) when (ex is WebSocketException or OperationCanceledException)
+        {
+            _inner.Abort();
+        }
+    }
+
+    private record OutboundFrame(
+        byte[] Buffer,
+        WebSocketMessageType MessageType,
+        bool EndOfMessage
+    );
+}
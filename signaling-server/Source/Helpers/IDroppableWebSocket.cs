@@ -0,0 +1,12 @@
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// A socket that drops its peer instead of queueing for it without bound.
+/// </summary>
+public interface IDroppableWebSocket
+{
+    /// <summary>
+    /// Whether the socket was closed because its outbound queue overflowed.
+    /// </summary>
+    bool IsDropped { get; }
+}
@@ -0,0 +1,47 @@
+using System.Security.Cryptography;
+using System.Text;
+
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// Salted hash of a room's join secret. The plaintext is never kept.
+/// </summary>
+public sealed class JoinSecret
+{
+    private const int SaltBytes = 16;
+    private const int HashBytes = 32;
+
+    // Rooms are short-lived and the hash never leaves memory, so a modest work factor keeps
+    // joins, including floods of wrong guesses, cheap
+    private const int Iterations = 10_000;
+
+    private readonly byte[] _salt;
+    private readonly byte[] _hash;
+
+    private JoinSecret(byte[] salt, byte[] hash)
+    {
+        _salt = salt;
+        _hash = hash;
+    }
+
+    public static JoinSecret Create(string secret)
+    {
+        var salt = RandomNumberGenerator.GetBytes(SaltBytes);
+        return new JoinSecret(salt, Derive(secret, salt));
+    }
+
+    /// <summary>
+    /// Checks a presented secret in constant time.
+    /// </summary>
+    public bool Matches(string? secret) =>
+        secret != null && CryptographicOperations.FixedTimeEquals(Derive(secret, _salt), _hash);
+
+    private static byte[] Derive(string secret, byte[] salt) =>
+        Rfc2898DeriveBytes.Pbkdf2(
+            Encoding.UTF8.GetBytes(secret),
+            salt,
+            Iterations,
+            HashAlgorithmName.SHA256,
+            HashBytes
+        );
+}
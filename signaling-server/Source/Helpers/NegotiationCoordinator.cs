@@ -0,0 +1,11 @@
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// Decides which side of a peer pair sends the WebRTC offer, so two peers never both initiate
+/// (glare). The rule is symmetric: for any two distinct ids exactly one side is told to offer.
+/// </summary>
+public static class NegotiationCoordinator
+{
+    public static bool ShouldOffer(string peerId, string otherPeerId) =>
+        string.CompareOrdinal(peerId, otherPeerId) < 0;
+}
@@ -0,0 +1,250 @@
+using System.Net.WebSockets;
+using System.Text;
+using System.Threading.Channels;
+using SignalingServer.Services;
+
+namespace SignalingServer.Helpers;
+
+/// <summary>
+/// Presents an HTTP long-poll session as a WebSocket, so polling peers go through the same
+/// connection handler, registry and routing as WebSocket peers. Messages the peer posts are
+/// read by the receive loop; messages sent to it are queued until its next poll. Binary frames
+/// can't be carried in a poll response and are discarded.
+/// </summary>
+public class PollingWebSocket : WebSocket, IDroppableWebSocket
+{
+    private readonly Channel<byte[]> _inbound;
+    private readonly Channel<string> _outbound;
+    private readonly TimeProvider _timeProvider;
+    private readonly Lock _stateLock = new();
+    private volatile WebSocketState _state = WebSocketState.Open;
+    private WebSocketCloseStatus? _closeStatus;
+    private string? _closeStatusDescription;
+    private long _lastActivityTicks;
+    private int _dropped;
+
+    // The message the receive loop is part way through reading
+    private byte[]? _pending;
+    private int _pendingOffset;
+
+    /// <param name="sessionId">The id the peer polls with.</param>
+    /// <param name="capacity">Maximum number of queued messages in each direction.</param>
+    /// <param name="timeProvider">Clock for the session's last activity.</param>
+    public PollingWebSocket(string sessionId, int capacity, TimeProvider timeProvider)
+    {
+        SessionId = sessionId;
+        _timeProvider = timeProvider;
+        _inbound = Channel.CreateBounded<byte[]>(
+            new BoundedChannelOptions(capacity) { SingleReader = true }
+        );
+        _outbound = Channel.CreateBounded<string>(new BoundedChannelOptions(capacity));
+        Touch();
+    }
+
+    public string SessionId { get; }
+
+    /// <summary>
+    /// When the peer last polled or posted a message.
+    /// </summary>
+    public DateTimeOffset LastActivity =>
+        new(Interlocked.Read(ref _lastActivityTicks), TimeSpan.Zero);
+
+    /// <summary>
+    /// Whether the session has ended and the peer has collected every message queued for it.
+    /// </summary>
+    public bool IsDrained => _outbound.Reader.Completion.IsCompleted;
+
+    /// <summary>
+    /// Whether the session was ended because the peer let its queue of messages fill up.
+    /// </summary>
+    public bool IsDropped => Volatile.Read(ref _dropped) == 1;
+
+    public override WebSocketCloseStatus? CloseStatus => _closeStatus;
+    public override string? CloseStatusDescription => _closeStatusDescription;
+    public override WebSocketState State => _state;
+    public override string? SubProtocol => null;
+
+    /// <summary>
+    /// Queues a message posted by the peer for the receive loop.
+    /// </summary>
+    /// <returns><c>false</c> if too many posted messages are still waiting to be handled.</returns>
+    public bool TryEnqueue(string raw)
+    {
+        Touch();
+        return _inbound.Writer.TryWrite(Encoding.UTF8.GetBytes(raw));
+    }
+
+    /// <summary>
+    /// Waits until a message is queued for the peer or the timeout passes, then takes every
+    /// queued message.
+    /// </summary>
+    /// <returns>The messages in the order they were sent; empty if none arrived in time.</returns>
+    public async Task<IReadOnlyList<string>> PollAsync(
+        TimeSpan timeout,
+        CancellationToken cancellationToken
+    )
+    {
+        Touch();
+        using var timeoutCts = new CancellationTokenSource(timeout, _timeProvider);
+        using var cts = CancellationTokenSource.CreateLinkedTokenSource(
+            cancellationToken,
+            timeoutCts.Token
+        );
+
+        try
+        {
+            await _outbound.Reader.WaitToReadAsync(cts.Token);
+        }
+        catch (OperationCanceledException) when (!cancellationToken.IsCancellationRequested)
+        {
+            // Nothing arrived before the timeout
+        }
+
+        var messages = new List<string>();
+        while (_outbound.Reader.TryRead(out var message))
+        {
+            messages.Add(message);
+        }
+
+        Touch();
+        return messages;
+    }
+
+    /// <summary>
+    /// Ends the session from the peer's side, as if it had sent a normal close frame.
+    /// </summary>
+    public void Disconnect()
+    {
+        lock (_stateLock)
+        {
+            if (_state != WebSocketState.Open)
+                return;
+
+            _state = WebSocketState.CloseReceived;
+            _closeStatus = WebSocketCloseStatus.NormalClosure;
+        }
+
+        _inbound.Writer.TryComplete();
+    }
+
+    public override Task SendAsync(
+        ArraySegment<byte> buffer,
+        WebSocketMessageType messageType,
+        bool endOfMessage,
+        CancellationToken cancellationToken
+    )
+    {
+        if (messageType != WebSocketMessageType.Text)
+            return Task.CompletedTask;
+
+        // A peer that stopped polling is dropped rather than queued for without bound
+        var queued = _outbound.Writer.TryWrite(Encoding.UTF8.GetString(buffer.AsSpan()));
+        if (!queued && _state == WebSocketState.Open)
+        {
+            Volatile.Write(ref _dropped, 1);
+            SignalingMetrics.DroppedSlowConsumers.Inc();
+            Abort();
+        }
+
+        return Task.CompletedTask;
+    }
+
+    public override async Task<WebSocketReceiveResult> ReceiveAsync(
+        ArraySegment<byte> buffer,
+        CancellationToken cancellationToken
+    )
+    {
+        while (_pending == null)
+        {
+            if (!await _inbound.Reader.WaitToReadAsync(cancellationToken))
+            {
+                if (_state == WebSocketState.Aborted)
+                    throw new WebSocketException(WebSocketError.ConnectionClosedPrematurely);
+
+                return new WebSocketReceiveResult(
+                    0,
+                    WebSocketMessageType.Close,
+                    true,
+                    _closeStatus,
+                    _closeStatusDescription
+                );
+            }
+
+            _inbound.Reader.TryRead(out _pending);
+        }
+
+        var count = Math.Min(buffer.Count, _pending.Length - _pendingOffset);
+        _pending.AsSpan(_pendingOffset, count).CopyTo(buffer.AsSpan());
+        _pendingOffset += count;
+
+        var endOfMessage = _pendingOffset == _pending.Length;
+        if (endOfMessage)
+        {
+            _pending = null;
+            _pendingOffset = 0;
+        }
+
+        return new WebSocketReceiveResult(count, WebSocketMessageType.Text, endOfMessage);
+    }
+
+    public override Task CloseAsync(
+        WebSocketCloseStatus closeStatus,
+        string? statusDescription,
+        CancellationToken cancellationToken
+    )
+    {
+        Close(closeStatus, statusDescription);
+        return Task.CompletedTask;
+    }
+
+    public override Task CloseOutputAsync(
+        WebSocketCloseStatus closeStatus,
+        string? statusDescription,
+        CancellationToken cancellationToken
+    )
+    {
+        Close(closeStatus, statusDescription);
+        return Task.CompletedTask;
+    }
+
+    public override void Abort()
+    {
+        lock (_stateLock)
+        {
+            if (_state is WebSocketState.Closed or WebSocketState.Aborted)
+                return;
+
+            _state = WebSocketState.Aborted;
+        }
+
+        _inbound.Writer.TryComplete();
+        _outbound.Writer.TryComplete();
+    }
+
+    public override void Dispose()
+    {
+        _inbound.Writer.TryComplete();
+        _outbound.Writer.TryComplete();
+    }
+
+    // A polling peer can't answer a close frame, so closing the output also ends the receive
+    // loop. Messages already queued stay available to the peer's next poll.
+    private void Close(WebSocketCloseStatus closeStatus, string? statusDescription)
+    {
+        lock (_stateLock)
+        {
+            if (_state is WebSocketState.Closed or WebSocketState.Aborted)
+                return;
+
+            _state = WebSocketState.Closed;
+            _closeStatus ??= closeStatus;
+            _closeStatusDescription ??= statusDescription;
+        }
+
+        _inbound.Writer.TryComplete();
+        _outbound.Writer.TryComplete();
+    }
+
+    private void Touch() =>
+        Interlocked.Exchange(ref _lastActivityTicks, _timeProvider.GetUtcNow().UtcTicks);
+}
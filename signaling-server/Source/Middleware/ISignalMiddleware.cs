@@ -0,0 +1,15 @@
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// Continues a signal message through the rest of the pipeline.
+/// </summary>
+public delegate Task SignalDelegate(SignalContext context);
+
+/// <summary>
+/// A step around the core message routing. Middleware runs in registration order and may
+/// short-circuit the message by replying to the sender and not calling <c>next</c>.
+/// </summary>
+public interface ISignalMiddleware
+{
+    Task InvokeAsync(SignalContext context, SignalDelegate next);
+}
@@ -0,0 +1,30 @@
+using SignalingServer.Extensions;
+using SignalingServer.Models;
+using SignalingServer.Services;
+
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// Rejects messages the sender isn't authorized to send. The connection stays open.
+/// </summary>
+public class SignalAuthorizationMiddleware(
+    IRoomAuthorizer authorizer,
+    ILogger<SignalAuthorizationMiddleware> logger
+) : ISignalMiddleware
+{
+    public async Task InvokeAsync(SignalContext context, SignalDelegate next)
+    {
+        if (!authorizer.CanSend(context.Socket, context.Message))
+        {
+            logger.LogWarning("Not authorized to send {Type}", context.Message.Type);
+            await context.Socket.SendErrorAsync(
+                $"Not allowed to send {context.Message.Type}",
+                SignalErrorCodes.Forbidden,
+                context.Message.RequestId
+            );
+            return;
+        }
+
+        await next(context);
+    }
+}
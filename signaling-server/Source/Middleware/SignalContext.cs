@@ -0,0 +1,12 @@
+using System.Net.WebSockets;
+using SignalingServer.Models;
+
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// A parsed signal message together with the connection it was received on.
+/// </summary>
+/// <param name="Socket">The sender's WebSocket, used to reply to it.</param>
+/// <param name="Message">The deserialized message.</param>
+/// <param name="Raw">The message as received, before parsing.</param>
+public record SignalContext(WebSocket Socket, SignalMessage Message, string Raw);
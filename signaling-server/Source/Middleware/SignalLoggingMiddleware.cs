@@ -0,0 +1,30 @@
+using System.Diagnostics;
+using SignalingServer.Extensions;
+
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// Logs every message that reaches it and how long the rest of the pipeline took.
+/// </summary>
+public class SignalLoggingMiddleware(ILogger<SignalLoggingMiddleware> logger) : ISignalMiddleware
+{
+    public async Task InvokeAsync(SignalContext context, SignalDelegate next)
+    {
+        // Never write a room's join secret to the logs
+        var raw = context.Message.JoinSecret == null ? context.Raw : "[redacted]";
+        logger.LogDebug(
+            "Received [{MessageType}] {Raw}",
+            context.Message.Type,
+            raw.TruncateForLogging()
+        );
+
+        var stopwatch = Stopwatch.StartNew();
+        await next(context);
+
+        logger.LogDebug(
+            "[{MessageType}] processed in {LatencyMs} ms",
+            context.Message.Type,
+            stopwatch.Elapsed.TotalMilliseconds
+        );
+    }
+}
@@ -0,0 +1,48 @@
+using SignalingServer.Extensions;
+using SignalingServer.Models;
+
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// Restricts which message types a deployment accepts. Messages of any other type are
+/// rejected with a <see cref="SignalErrorCodes.TypeNotAllowed"/> error before routing.
+/// </summary>
+public class SignalTypeFilterMiddleware(
+    ILogger<SignalTypeFilterMiddleware> logger,
+    string? allowedTypes = null
+) : ISignalMiddleware
+{
+    /// <summary>
+    /// Types configured through ALLOWED_MSG_TYPES (comma-separated), or null to allow all.
+    /// </summary>
+    private readonly HashSet<string>? _allowedTypes = ParseAllowedTypes(
+        allowedTypes ?? Environment.GetEnvironmentVariable("ALLOWED_MSG_TYPES")
+    );
+
+    public async Task InvokeAsync(SignalContext context, SignalDelegate next)
+    {
+        var type = context.Message.Type;
+        if (_allowedTypes != null && (type == null || !_allowedTypes.Contains(type)))
+        {
+            logger.LogWarning("Rejected message of disallowed type {Type}", type);
+            await context.Socket.SendErrorAsync(
+                $"Message type {type} is not allowed",
+                SignalErrorCodes.TypeNotAllowed,
+                context.Message.RequestId
+            );
+            return;
+        }
+
+        await next(context);
+    }
+
+    private static HashSet<string>? ParseAllowedTypes(string? value)
+    {
+        if (string.IsNullOrWhiteSpace(value))
+            return null;
+
+        return value
+            .Split(',', StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries)
+            .ToHashSet(StringComparer.OrdinalIgnoreCase);
+    }
+}
@@ -0,0 +1,34 @@
+using SignalingServer.Extensions;
+using SignalingServer.Models;
+using SignalingServer.Validation;
+
+namespace SignalingServer.Middleware;
+
+/// <summary>
+/// Rejects messages that fail <see cref="SignalMessageValidator"/>. Routing relies on these
+/// rules, so the message handler always runs this first.
+/// </summary>
+public class SignalValidationMiddleware(ILogger logger) : ISignalMiddleware
+{
+    private static readonly SignalMessageValidator Validator = new();
+
+    public async Task InvokeAsync(SignalContext context, SignalDelegate next)
+    {
+        var validationResult = await Validator.ValidateAsync(context.Message);
+
+        if (!validationResult.IsValid)
+        {
+            var errors = validationResult.Errors.Select(e => e.ErrorMessage).ToList();
+            logger.LogWarning("Validation failed: {Errors}", string.Join("; ", errors));
+            await context.Socket.SendErrorAsync(
+                "Validation failed",
+                SignalErrorCodes.BadMessage,
+                context.Message.RequestId,
+                string.Join(", ", errors)
+            );
+            return;
+        }
+
+        await next(context);
+    }
+}
@@ -0,0 +1,9 @@
+using System.Text.Json.Serialization;
+
+namespace SignalingServer.Models;
+
+public class KickRequest
+{
+    [JsonPropertyName("reason")]
+    public string? Reason { get; set; }
+}
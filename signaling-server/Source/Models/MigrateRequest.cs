@@ -0,0 +1,9 @@
+using System.Text.Json.Serialization;
+
+namespace SignalingServer.Models;
+
+public class MigrateRequest
+{
+    [JsonPropertyName("url")]
+    public string? Url { get; set; }
+}
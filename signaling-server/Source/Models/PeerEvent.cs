@@ -0,0 +1,27 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// A connection lifecycle event, pushed to the webhook configured with WEBHOOK_URL.
+/// </summary>
+/// <param name="Type">One of <see cref="PeerEventTypes"/>.</param>
+/// <param name="Timestamp">When the event happened.</param>
+/// <param name="ConnectionId">Identifies the WebSocket connection across its events.</param>
+/// <param name="HostId">The room the event concerns, for room events.</param>
+/// <param name="PeerId">The host or client id of the peer, for room events.</param>
+/// <param name="Role">Whether the peer is the room's host or one of its clients.</param>
+public record PeerEvent(
+    string Type,
+    DateTimeOffset Timestamp,
+    string? ConnectionId,
+    string? HostId = null,
+    string? PeerId = null,
+    PeerRole? Role = null
+);
+
+public static class PeerEventTypes
+{
+    public const string PeerConnected = "peer-connected";
+    public const string RoomJoined = "room-joined";
+    public const string RoomLeft = "room-left";
+    public const string PeerDisconnected = "peer-disconnected";
+}
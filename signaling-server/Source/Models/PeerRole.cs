@@ -0,0 +1,7 @@
+namespace SignalingServer.Models;
+
+public enum PeerRole
+{
+    Host,
+    Client,
+}
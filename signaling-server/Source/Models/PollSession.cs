@@ -0,0 +1,8 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// Returned when a long-poll session is opened.
+/// </summary>
+/// <param name="SessionId">Identifies the peer on later polls, sent in the X-Poll-Session header.</param>
+/// <param name="PollTimeoutMs">How long a receive request may wait before it returns empty.</param>
+public record PollSession(string SessionId, int PollTimeoutMs);
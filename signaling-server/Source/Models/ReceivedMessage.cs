@@ -0,0 +1,10 @@
+using System.Net.WebSockets;
+
+namespace SignalingServer.Models;
+
+/// <summary>
+/// A complete WebSocket message as read off the wire.
+/// </summary>
+/// <param name="MessageType">Whether the message arrived as text or binary frames.</param>
+/// <param name="Data">The reassembled message bytes.</param>
+public record ReceivedMessage(WebSocketMessageType MessageType, byte[] Data);
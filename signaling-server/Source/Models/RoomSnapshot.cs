@@ -0,0 +1,29 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// Point-in-time view of a host and the clients attached to it, as exposed by the admin API.
+/// </summary>
+/// <param name="HostId">The host id, which doubles as the room id.</param>
+/// <param name="Namespace">The namespace the room was opened in.</param>
+/// <param name="ConnectedAt">When the host's connection was opened, if it is tracked.</param>
+/// <param name="MaxClients">The capacity the host registered with.</param>
+/// <param name="Clients">The clients in the room, oldest connection first.</param>
+public record RoomSnapshot(
+    string HostId,
+    string Namespace,
+    DateTimeOffset? ConnectedAt,
+    int MaxClients,
+    IReadOnlyList<PeerSnapshot> Clients
+)
+{
+    public int MemberCount => Clients.Count;
+}
+
+/// <param name="PeerId">The client id.</param>
+/// <param name="ConnectedAt">When the client's connection was opened, if it is tracked.</param>
+public record PeerSnapshot(string PeerId, DateTimeOffset? ConnectedAt);
+
+/// <param name="Namespace">The namespace name.</param>
+/// <param name="Connections">Open connections in the namespace.</param>
+/// <param name="Rooms">Registered hosts in the namespace.</param>
+public record NamespaceSnapshot(string Namespace, int Connections, int Rooms);
@@ -0,0 +1,17 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// The identity carried by a reconnection token.
+/// </summary>
+/// <param name="Role">Whether the identity belongs to a host or a client.</param>
+/// <param name="PeerId">The host or client id to reclaim.</param>
+/// <param name="HostId">The host the peer belongs to; equal to <paramref name="PeerId"/> for hosts.</param>
+/// <param name="Namespace">The namespace the peer was connected to; ids are only reclaimed in it.</param>
+/// <param name="ExpiresAt">When the token stops being accepted.</param>
+public record SessionToken(
+    PeerRole Role,
+    string PeerId,
+    string HostId,
+    string Namespace,
+    DateTimeOffset ExpiresAt
+);
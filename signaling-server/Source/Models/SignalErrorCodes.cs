@@ -0,0 +1,22 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// Machine-readable codes sent in the <c>code</c> field of every <c>error</c> message.
+/// </summary>
+public static class SignalErrorCodes
+{
+    public const string BadMessage = "bad-message";
+    public const string UnknownType = "unknown-type";
+    public const string NotRegistered = "not-registered";
+    public const string InvalidRoomId = "invalid-room-id";
+    public const string HostNotFound = "host-not-found";
+    public const string PeerUnavailable = "peer-unavailable";
+    public const string RoomFull = "room-full";
+    public const string Forbidden = "forbidden";
+    public const string BadSecret = "bad-secret";
+    public const string Migrating = "migrating";
+    public const string MetadataTooLarge = "metadata-too-large";
+    public const string SlowConsumer = "slow-consumer";
+    public const string RateLimited = "rate-limited";
+    public const string TypeNotAllowed = "type-not-allowed";
+}
@@ -7,6 +7,18 @@ public class SignalErrorResponse
     [JsonPropertyName("type")]
     public string Type { get; set; } = default!;
 
+    [JsonPropertyName("code")]
+    public string? Code { get; set; }
+
     [JsonPropertyName("message")]
     public string? Message { get; set; }
+
+    /// <summary>
+    /// Optional specifics for the failure, such as the individual validation errors.
+    /// </summary>
+    [JsonPropertyName("detail")]
+    public string? Detail { get; set; }
+
+    [JsonPropertyName("requestId")]
+    public string? RequestId { get; set; }
 }
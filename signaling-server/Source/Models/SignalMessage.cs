@@ -1,3 +1,4 @@
+using System.Text.Json;
 using System.Text.Json.Serialization;
 
 namespace SignalingServer.Models;
@@ -13,6 +14,9 @@ public class SignalMessage
     [JsonPropertyName("clientId")]
     public string? ClientId { get; set; }
 
+    [JsonPropertyName("clientIds")]
+    public IReadOnlyList<string>? ClientIds { get; set; }
+
     [JsonPropertyName("payload")]
     public string? Payload { get; set; }
 
@@ -21,4 +25,41 @@ public class SignalMessage
 
     [JsonPropertyName("maxClients")]
     public int? MaxClients { get; set; }
+
+    [JsonPropertyName("url")]
+    public string? Url { get; set; }
+
+    /// <summary>
+    /// Why a relayed message was not delivered, set on <c>nack</c> messages.
+    /// </summary>
+    [JsonPropertyName("reason")]
+    public string? Reason { get; set; }
+
+    [JsonPropertyName("reconnectToken")]
+    public string? ReconnectToken { get; set; }
+
+    /// <summary>
+    /// Password a host sets on its room and that joiners must present. Only a hash is kept.
+    /// </summary>
+    [JsonPropertyName("joinSecret")]
+    public string? JoinSecret { get; set; }
+
+    /// <summary>
+    /// Whether the recipient should send the offer to the peer named in the message. Set on
+    /// <c>join-host</c> and <c>client-joined</c>; the two sides always get opposite values.
+    /// </summary>
+    [JsonPropertyName("shouldOffer")]
+    public bool? ShouldOffer { get; set; }
+
+    /// <summary>
+    /// Opaque, client-defined JSON describing the peer (e.g. display name or role).
+    /// </summary>
+    [JsonPropertyName("metadata")]
+    public JsonElement? Metadata { get; set; }
+
+    /// <summary>
+    /// Latest metadata of the clients listed in <see cref="ClientIds"/>, keyed by client id.
+    /// </summary>
+    [JsonPropertyName("peerMetadata")]
+    public IReadOnlyDictionary<string, JsonElement>? PeerMetadata { get; set; }
 }
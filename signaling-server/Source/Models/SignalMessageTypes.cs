@@ -6,10 +6,17 @@ public static class SignalMessageTypes
     public const string JoinHost = "join-host";
     public const string MsgToHost = "msg-to-host";
     public const string MsgToClient = "msg-to-client";
+    public const string SetMetadata = "set-metadata";
 
     public const string ClientJoined = "client-joined";
     public const string ClientDisconnected = "client-disconnected";
+    public const string RoomState = "room-state";
+    public const string PeerUpdated = "peer-updated";
+
+    public const string Ack = "ack";
+    public const string Nack = "nack";
 
     public const string HostDisconnected = "host-disconnected";
+    public const string Migrate = "migrate";
     public const string Error = "error";
 }
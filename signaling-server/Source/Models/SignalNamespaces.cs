@@ -0,0 +1,13 @@
+namespace SignalingServer.Models;
+
+/// <summary>
+/// Namespaces isolate the rooms of different apps sharing one server. A connection picks its
+/// namespace with the <c>/ws/{namespace}</c> path and can only join rooms opened in it.
+/// </summary>
+public static class SignalNamespaces
+{
+    /// <summary>
+    /// The namespace of connections to plain <c>/ws</c>.
+    /// </summary>
+    public const string Default = "default";
+}
@@ -0,0 +1,18 @@
+using System.Text.Json.Serialization;
+
+namespace SignalingServer.Models;
+
+public class TurnCredentials
+{
+    [JsonPropertyName("username")]
+    public string Username { get; set; } = default!;
+
+    [JsonPropertyName("password")]
+    public string Password { get; set; } = default!;
+
+    [JsonPropertyName("ttl")]
+    public int Ttl { get; set; }
+
+    [JsonPropertyName("urls")]
+    public IReadOnlyList<string> Urls { get; set; } = [];
+}
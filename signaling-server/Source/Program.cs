@@ -1,32 +1,156 @@
+using OpenTelemetry.Resources;
+using OpenTelemetry.Trace;
+using Prometheus;
 using Serilog;
+using Serilog.Events;
+using SignalingServer.Configuration;
 using SignalingServer.Endpoints;
+using SignalingServer.Middleware;
 using SignalingServer.Services;
 using SignalingServer.Validation;
 
 var builder = WebApplication.CreateBuilder(args);
 
+// LOG_LEVEL (e.g. "debug", "warn") overrides the configured minimum level
+var logLevelSetting = Environment.GetEnvironmentVariable("LOG_LEVEL");
+LogEventLevel? logLevelOverride = LogLevels.TryParse(logLevelSetting, out var parsedLogLevel)
+    ? parsedLogLevel
+    : null;
+
 builder.Host.UseSerilog(
     (context, configuration) =>
     {
         configuration.ReadFrom.Configuration(context.Configuration);
+
+        if (logLevelOverride is { } logLevel)
+        {
+            configuration.MinimumLevel.Is(logLevel);
+        }
     }
 );
 
 // Configure WebSocket options
 builder.Services.Configure<WebSocketOptions>(options =>
 {
-    options.KeepAliveInterval = TimeSpan.FromSeconds(30); // Send keep-alive every 30 seconds
+    // Ping every interval; abort connections that don't answer with a pong within the timeout,
+    // which ends the receive loop and runs the normal disconnect cleanup.
+    options.KeepAliveInterval = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("PING_INTERVAL_SECONDS") ?? "30")
+    );
+    options.KeepAliveTimeout = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("PONG_TIMEOUT_SECONDS") ?? "15")
+    );
 });
 
+// Give open connections time to receive a going-away close frame before the host stops
+builder.Services.Configure<HostOptions>(options =>
+{
+    options.ShutdownTimeout = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("SHUTDOWN_GRACE_PERIOD_SECONDS") ?? "30")
+    );
+});
+
+builder.Services.AddSingleton(MessageRateLimitOptions.FromEnvironment());
 builder.Services.AddSingleton<IConnectionHandler, ConnectionHandler>();
 builder.Services.AddSingleton<IMessageHandler, MessageHandler>();
+// Built eagerly so an invalid ROOM_ID_PATTERN stops the server at startup
+builder.Services.AddSingleton(new RoomIdValidator());
+builder.Services.AddSingleton<IRoomAuthorizer>(serviceProvider =>
+    JwtAuthOptions.FromEnvironment().IsEnabled
+        ? new TokenClaimsAuthorizer(serviceProvider.GetRequiredService<ISignalRegistry>())
+        : new AllowAllAuthorizer()
+);
+// Message middleware runs in registration order, after validation and before routing
+builder.Services.AddSingleton<ISignalMiddleware, SignalAuthorizationMiddleware>();
+builder.Services.AddSingleton<ISignalMiddleware, SignalTypeFilterMiddleware>();
+builder.Services.AddSingleton<ISignalMiddleware, SignalLoggingMiddleware>();
 builder.Services.AddSingleton<ISignalRegistry, SignalRegistry>();
+builder.Services.AddSingleton(serviceProvider =>
+{
+    var logger = serviceProvider.GetRequiredService<ILogger<WebhookNotifier>>();
+    return new WebhookNotifier(WebhookOptions.FromEnvironment(), new HttpClient(), logger);
+});
+builder.Services.AddSingleton<IPeerEventPublisher>(serviceProvider =>
+    serviceProvider.GetRequiredService<WebhookNotifier>()
+);
+builder.Services.AddHostedService(serviceProvider =>
+    serviceProvider.GetRequiredService<WebhookNotifier>()
+);
 builder.Services.AddSingleton<OriginValidator>();
+builder.Services.AddSingleton(ConnectionLimiter.FromEnvironment());
+builder.Services.AddSingleton(serviceProvider =>
+{
+    var maxConnections = serviceProvider.GetRequiredService<ConnectionLimiter>().MaxConnections;
+    var signalRegistry = serviceProvider.GetRequiredService<ISignalRegistry>();
+    return new ReadinessState(signalRegistry, maxConnections);
+});
+builder.Services.AddHostedService<WebSocketShutdownService>();
+builder.Services.AddSingleton(LongPollOptions.FromEnvironment());
+builder.Services.AddSingleton<LongPollSessions>();
+builder.Services.AddHostedService(serviceProvider =>
+    serviceProvider.GetRequiredService<LongPollSessions>()
+);
+builder.Services.AddSingleton(TimeProvider.System);
+builder.Services.AddHostedService(serviceProvider =>
+{
+    // ROOM_IDLE_TTL is in seconds; 0 (the default) leaves idle rooms open
+    var idleTimeout = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("ROOM_IDLE_TTL") ?? "0")
+    );
+    var sweepInterval = TimeSpan.FromSeconds(
+        int.Parse(Environment.GetEnvironmentVariable("ROOM_SWEEP_INTERVAL_SECONDS") ?? "60")
+    );
+    return new IdleRoomSweeper(
+        serviceProvider.GetRequiredService<ISignalRegistry>(),
+        idleTimeout,
+        sweepInterval,
+        serviceProvider.GetRequiredService<TimeProvider>(),
+        serviceProvider.GetRequiredService<ILogger<IdleRoomSweeper>>()
+    );
+});
+builder.Services.AddSingleton(TurnCredentialGenerator.FromEnvironment());
+builder.Services.AddSingleton(SessionTokenService.FromEnvironment());
+builder.Services.AddSingleton(serviceProvider =>
+{
+    var logger = serviceProvider.GetRequiredService<ILogger<JwtAuthenticator>>();
+    return new JwtAuthenticator(
+        JwtAuthOptions.FromEnvironment(),
+        new HttpClient(),
+        TimeProvider.System,
+        logger
+    );
+});
+
+// Spans are exported only when an OTLP endpoint is configured. Without a listener on the
+// activity source no spans are created at all.
+if (!string.IsNullOrWhiteSpace(Environment.GetEnvironmentVariable("OTEL_EXPORTER_OTLP_ENDPOINT")))
+{
+    builder
+        .Services.AddOpenTelemetry()
+        .ConfigureResource(resource => resource.AddService("signaling-server"))
+        .WithTracing(tracing => tracing.AddSource(SignalingTracing.SourceName).AddOtlpExporter());
+}
 
 builder.Services.AddCors();
+builder.Services.AddConnectionRateLimiting();
 
 var app = builder.Build();
 
+app.Logger.LogInformation(
+    "Signaling server commit {Commit}, built {BuildTime}, running on {Runtime}",
+    BuildInfo.Current.Commit,
+    BuildInfo.Current.BuildTime,
+    BuildInfo.Current.Runtime
+);
+
+if (!string.IsNullOrWhiteSpace(logLevelSetting) && logLevelOverride == null)
+{
+    app.Logger.LogWarning(
+        "Ignoring unrecognized LOG_LEVEL {LogLevel}; using the configured minimum level",
+        logLevelSetting
+    );
+}
+
 app.UseCors(policyBuilder =>
 {
     var validator = app.Services.GetRequiredService<OriginValidator>();
@@ -37,12 +161,25 @@ app.UseCors(policyBuilder =>
         .AllowCredentials();
 });
 
+app.UseRateLimiter();
 app.UseWebSockets();
 
 // Map endpoints
 app.MapWebSocketEndpoints();
+app.MapPollEndpoints();
 app.MapHomeEndpoints();
 app.MapHealthEndpoints();
 app.MapApiSpecEndpoints();
+app.MapTurnEndpoints();
+app.MapAdminEndpoints();
+app.MapVersionEndpoints();
+app.MapMetrics();
+
+// The per-namespace breakdown is computed from the registry on each scrape
+Metrics.DefaultRegistry.AddBeforeCollectCallback(() =>
+    SignalingMetrics.RecordNamespaces(
+        app.Services.GetRequiredService<ISignalRegistry>().GetNamespaceSnapshots()
+    )
+);
 
 app.Run();
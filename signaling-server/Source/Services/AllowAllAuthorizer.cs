@@ -0,0 +1,14 @@
+using System.Net.WebSockets;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Lets every connection join any host and send any message, for deployments without tokens.
+/// </summary>
+public class AllowAllAuthorizer : IRoomAuthorizer
+{
+    public bool CanJoin(WebSocket socket, string hostId) => true;
+
+    public bool CanSend(WebSocket socket, SignalMessage message) => true;
+}
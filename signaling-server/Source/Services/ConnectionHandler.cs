@@ -1,4 +1,8 @@
+using System.Diagnostics;
 using System.Net.WebSockets;
+using System.Text;
+using System.Threading.RateLimiting;
+using SignalingServer.Configuration;
 using SignalingServer.Extensions;
 using SignalingServer.Models;
 
@@ -7,7 +11,8 @@ namespace SignalingServer.Services;
 public class ConnectionHandler(
     IMessageHandler messageHandler,
     ISignalRegistry signalRegistry,
-    ILogger<ConnectionHandler> logger
+    ILogger<ConnectionHandler> logger,
+    MessageRateLimitOptions? rateLimitOptions = null
 ) : IConnectionHandler
 {
     private static readonly int MaxMessageSize = int.Parse(
@@ -17,39 +22,117 @@ public class ConnectionHandler(
         Environment.GetEnvironmentVariable("WEBSOCKET_CHUNK_SIZE") ?? "4096"
     ); // 4KB default
 
+    // Never sent on the wire: stands for a connection that dropped without a close frame
+    private const int AbnormalClosureCode = 1006;
+
     public event Action<WebSocket, DisconnectionType>? SocketDisconnected;
 
-    public async Task HandleConnection(WebSocket socket, CancellationToken cancellationToken)
+    public async Task HandleConnection(
+        WebSocket socket,
+        CancellationToken cancellationToken,
+        ActivityContext parentContext = default
+    )
     {
+        // Every log line written while this connection is handled carries its correlation id
+        using var scope = logger.BeginScope(
+            new Dictionary<string, object> { ["ConnectionId"] = Guid.NewGuid().ToString("N") }
+        );
+
+        // Spans of the messages handled below become children of this one
+        using var activity = SignalingTracing.StartConnection(parentContext);
+
         logger.LogDebug("Trying to establish connection...");
 
         signalRegistry.TrackSocket(socket);
+        int? closeCode = null;
+        using var rateLimiter = rateLimitOptions?.CreateLimiter();
+        var rateLimitViolations = 0;
 
         try
         {
             while (socket.State == WebSocketState.Open)
             {
-                var raw = await socket.ReceiveFullMessageAsync(
+                // Binary frames are held to the same size limit as text ones
+                var message = await socket.ReceiveMessageAsync(
                     maxSizeInBytes: MaxMessageSize,
                     chunkSize: ChunkSize,
                     cancellationToken: cancellationToken
                 );
 
-                if (raw == null)
+                if (message == null)
                     break; // Closed or canceled
 
-                await messageHandler.HandleMessage(socket, raw);
+                if (rateLimiter != null && !TryAcquireMessageBudget(rateLimiter))
+                {
+                    rateLimitViolations++;
+                    SignalingMetrics.RateLimitedMessages.Inc();
+                    if (rateLimitViolations == 1)
+                    {
+                        logger.LogWarning("Connection exceeded its message rate limit");
+                    }
+
+                    await socket.SendErrorAsync(
+                        "Message rate limit exceeded",
+                        SignalErrorCodes.RateLimited
+                    );
+
+                    if (
+                        rateLimitOptions!.MaxViolations > 0
+                        && rateLimitViolations >= rateLimitOptions.MaxViolations
+                    )
+                    {
+                        logger.LogWarning(
+                            "Closing connection after {Violations} rate-limited messages",
+                            rateLimitViolations
+                        );
+                        closeCode = (int)WebSocketCloseStatus.PolicyViolation;
+                        await CloseSocket(
+                            socket,
+                            WebSocketCloseStatus.PolicyViolation,
+                            SignalErrorCodes.RateLimited
+                        );
+                        break;
+                    }
+
+                    continue;
+                }
+
+                var stopwatch = Stopwatch.StartNew();
+                if (message.MessageType == WebSocketMessageType.Binary)
+                {
+                    await messageHandler.HandleBinaryMessage(socket, message.Data);
+                }
+                else
+                {
+                    var raw = Encoding.UTF8.GetString(message.Data);
+                    await messageHandler.HandleMessage(socket, raw);
+                }
+                logger.LogDebug(
+                    "Message handled in {LatencyMs} ms",
+                    stopwatch.Elapsed.TotalMilliseconds
+                );
             }
         }
+        catch (MessageTooLargeException ex)
+        {
+            logger.LogWarning(ex, "Message too large, closing connection");
+            closeCode = (int)WebSocketCloseStatus.MessageTooBig;
+            await CloseSocket(socket, WebSocketCloseStatus.MessageTooBig, "Message too large");
+        }
         catch (WebSocketException ex)
         {
             logger.LogWarning(ex, "WebSocket error");
         }
         finally
         {
+            closeCode ??= socket.CloseStatus is { } status ? (int)status : AbnormalClosureCode;
+            SignalingMetrics.RecordDisconnect(closeCode.Value);
+            activity?.SetTag(SignalingTracing.CloseCodeAttribute, closeCode.Value);
+
+            // Peers are notified either way; only the log level tells the two kinds apart
             if (signalRegistry.TryGetHostId(socket, out var hostId))
             {
-                logger.LogInformation("Host {HostId} disconnected", hostId);
+                LogDisconnect(DisconnectionType.Host, hostId, closeCode.Value);
 
                 await messageHandler.HandleDisconnect(socket, DisconnectionType.Host);
 
@@ -58,7 +141,7 @@ public class ConnectionHandler(
             }
             else if (signalRegistry.TryGetClientHost(socket, out hostId))
             {
-                logger.LogInformation("Client disconnected from host {HostId}", hostId);
+                LogDisconnect(DisconnectionType.Client, hostId, closeCode.Value);
 
                 await messageHandler.HandleDisconnect(socket, DisconnectionType.Client);
 
@@ -67,7 +150,10 @@ public class ConnectionHandler(
             }
             else
             {
-                logger.LogInformation("Unregistered socket disconnected before registration");
+                logger.LogInformation(
+                    "Unregistered socket disconnected before registration (close code {CloseCode})",
+                    closeCode
+                );
 
                 await messageHandler.HandleDisconnect(socket, DisconnectionType.Unknown);
 
@@ -77,6 +163,43 @@ public class ConnectionHandler(
         }
     }
 
+    private static bool TryAcquireMessageBudget(TokenBucketRateLimiter rateLimiter)
+    {
+        rateLimiter.TryReplenish();
+        using var lease = rateLimiter.AttemptAcquire();
+        return lease.IsAcquired;
+    }
+
+    /// <summary>
+    /// Normal closures and going-away (page unload, server restart) are routine; any other code
+    /// usually points at a client bug or a network problem, so it is logged as a warning.
+    /// </summary>
+    private void LogDisconnect(DisconnectionType type, string hostId, int closeCode)
+    {
+        var isClean =
+            closeCode == (int)WebSocketCloseStatus.NormalClosure
+            || closeCode == (int)WebSocketCloseStatus.EndpointUnavailable;
+
+        if (isClean)
+        {
+            logger.LogInformation(
+                "{PeerType} of host {HostId} disconnected (close code {CloseCode})",
+                type,
+                hostId,
+                closeCode
+            );
+        }
+        else
+        {
+            logger.LogWarning(
+                "{PeerType} of host {HostId} disconnected abnormally (close code {CloseCode})",
+                type,
+                hostId,
+                closeCode
+            );
+        }
+    }
+
     private async Task CleanupHost(string hostId)
     {
         if (signalRegistry.TryGetHostSocket(hostId, out var hostSocket))
@@ -102,18 +225,18 @@ public class ConnectionHandler(
         await CloseSocket(socket);
     }
 
-    private async Task CloseSocket(WebSocket socket)
+    private async Task CloseSocket(
+        WebSocket socket,
+        WebSocketCloseStatus closeStatus = WebSocketCloseStatus.NormalClosure,
+        string statusDescription = "Closing"
+    )
     {
         if (socket.State is WebSocketState.Open or WebSocketState.CloseReceived)
         {
             try
             {
                 logger.LogDebug("Closing WebSocket with state: {State}", socket.State);
-                await socket.CloseAsync(
-                    WebSocketCloseStatus.NormalClosure,
-                    "Closing",
-                    CancellationToken.None
-                );
+                await socket.CloseAsync(closeStatus, statusDescription, CancellationToken.None);
             }
             catch (WebSocketException ex)
             {
@@ -0,0 +1,57 @@
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Hard ceiling on concurrent WebSocket connections across all rooms. A slot is taken before
+/// the upgrade is accepted and must be released when the connection ends, however it ends.
+/// </summary>
+public class ConnectionLimiter
+{
+    private readonly SemaphoreSlim? _slots;
+    private readonly LoadShedder? _loadShedder;
+    private int _activeConnections;
+
+    /// <param name="maxConnections">Maximum open connections; zero or less means unlimited.</param>
+    /// <param name="loadShedder">Optionally refuses connections below the ceiling under load.</param>
+    public ConnectionLimiter(int maxConnections, LoadShedder? loadShedder = null)
+    {
+        MaxConnections = maxConnections;
+        _loadShedder = loadShedder;
+        if (maxConnections > 0)
+        {
+            _slots = new SemaphoreSlim(maxConnections, maxConnections);
+            SignalingMetrics.MaxConnections.Set(maxConnections);
+        }
+    }
+
+    public int MaxConnections { get; }
+
+    public static ConnectionLimiter FromEnvironment() =>
+        new(
+            int.Parse(Environment.GetEnvironmentVariable("MAX_CONNECTIONS") ?? "0"),
+            LoadShedder.FromEnvironment()
+        );
+
+    /// <summary>
+    /// Takes a connection slot without waiting.
+    /// </summary>
+    /// <returns><c>false</c> if every slot is in use or load is being shed.</returns>
+    public bool TryAcquire()
+    {
+        // Shedding is a soft limit: concurrent upgrades may overshoot the high-water mark
+        // slightly, the hard ceiling is still enforced by the slots
+        if (_loadShedder?.IsShedding == true)
+            return false;
+
+        if (_slots != null && !_slots.Wait(0))
+            return false;
+
+        _loadShedder?.Update(Interlocked.Increment(ref _activeConnections));
+        return true;
+    }
+
+    public void Release()
+    {
+        _loadShedder?.Update(Interlocked.Decrement(ref _activeConnections));
+        _slots?.Release();
+    }
+}
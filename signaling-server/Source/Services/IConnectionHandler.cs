@@ -1,3 +1,4 @@
+using System.Diagnostics;
 using System.Net.WebSockets;
 using SignalingServer.Models;
 
@@ -6,5 +7,9 @@ namespace SignalingServer.Services;
 public interface IConnectionHandler
 {
     event Action<WebSocket, DisconnectionType>? SocketDisconnected;
-    Task HandleConnection(WebSocket socket, CancellationToken cancellationToken);
+    Task HandleConnection(
+        WebSocket socket,
+        CancellationToken cancellationToken,
+        ActivityContext parentContext = default
+    );
 }
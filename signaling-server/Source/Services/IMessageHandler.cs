@@ -6,5 +6,6 @@ namespace SignalingServer.Services;
 public interface IMessageHandler
 {
     Task HandleMessage(WebSocket socket, string raw);
+    Task HandleBinaryMessage(WebSocket socket, byte[] frame);
     Task HandleDisconnect(WebSocket socket, DisconnectionType type);
 }
@@ -0,0 +1,11 @@
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+public interface IPeerEventPublisher
+{
+    /// <summary>
+    /// Queues an event for delivery. Must not block: it is called on the signaling path.
+    /// </summary>
+    void Publish(PeerEvent peerEvent);
+}
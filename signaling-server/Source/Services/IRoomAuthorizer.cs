@@ -0,0 +1,20 @@
+using System.Net.WebSockets;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Decides what a connection may do beyond being authenticated.
+/// </summary>
+public interface IRoomAuthorizer
+{
+    /// <summary>
+    /// Called when the connection asks to join a host. A denial closes the connection.
+    /// </summary>
+    bool CanJoin(WebSocket socket, string hostId);
+
+    /// <summary>
+    /// Called for every message the connection sends. A denial rejects only that message.
+    /// </summary>
+    bool CanSend(WebSocket socket, SignalMessage message);
+}
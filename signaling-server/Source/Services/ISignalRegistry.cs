@@ -1,5 +1,7 @@
 using System.Diagnostics.CodeAnalysis;
 using System.Net.WebSockets;
+using System.Text.Json;
+using SignalingServer.Models;
 
 namespace SignalingServer.Services;
 
@@ -8,22 +10,66 @@ public interface ISignalRegistry
     Task<string> GenerateUniqueHostIdAsync();
     Task<string> GenerateUniqueClientIdAsync();
 
-    bool RegisterHost(string hostId, WebSocket socket, int maxClients = 10);
+    /// <summary>
+    /// Publishes the room, already protected by the join secret if one is given.
+    /// </summary>
+    /// <returns><c>false</c> if the host id is taken.</returns>
+    bool RegisterHost(
+        string hostId,
+        WebSocket socket,
+        int maxClients = 10,
+        string? joinSecret = null
+    );
 
     bool TryGetHostSocket(string hostId, [NotNullWhen(true)] out WebSocket? socket);
     bool TryGetHostId(WebSocket socket, [NotNullWhen(true)] out string? hostId);
     bool RemoveHost(string hostId);
+    bool MarkMigrating(string hostId);
+    bool IsMigrating(string hostId);
+    bool IsJoinSecretValid(string hostId, string? secret);
+
+    void RecordActivity(WebSocket socket);
+    IReadOnlyList<string> GetIdleHosts(TimeSpan idleTimeout);
     bool RemoveHost(WebSocket socket);
 
     bool RegisterClient(string clientId, WebSocket socket, string hostId);
+    bool RegisterClient(
+        string clientId,
+        WebSocket socket,
+        string hostId,
+        out IReadOnlyList<string> existingClientIds
+    );
+
+    bool ReplaceClient(
+        string clientId,
+        WebSocket socket,
+        string hostId,
+        [NotNullWhen(true)] out WebSocket? previousSocket
+    );
 
     bool TryGetClientSocket(string clientId, [NotNullWhen(true)] out WebSocket? clientSocket);
     bool TryGetClientId(WebSocket clientSocket, [NotNullWhen(true)] out string? clientId);
     bool TryGetClientHost(WebSocket clientSocket, [NotNullWhen(true)] out string? hostId);
     bool RemoveClient(WebSocket clientSocket);
 
+    void SetClientMetadata(WebSocket clientSocket, JsonElement metadata);
+    bool TryGetClientMetadata(string clientId, out JsonElement metadata);
+
     IEnumerable<WebSocket> GetClientsForHost(string hostId);
 
+    IReadOnlyList<RoomSnapshot> GetRoomSnapshots();
+    RoomSnapshot? GetRoomSnapshot(string hostId);
+    IReadOnlyList<NamespaceSnapshot> GetNamespaceSnapshots();
+
     void TrackSocket(WebSocket socket);
     void UntrackSocket(WebSocket socket);
+    IReadOnlyCollection<WebSocket> GetTrackedSockets();
+
+    void SetNamespace(WebSocket socket, string signalNamespace);
+    string GetNamespace(WebSocket socket);
+
+    void SetAllowedHosts(WebSocket socket, IReadOnlySet<string> hostIds);
+    bool IsHostAllowed(WebSocket socket, string hostId);
+    void SetAllowedActions(WebSocket socket, IReadOnlySet<string> messageTypes);
+    bool IsActionAllowed(WebSocket socket, string messageType);
 }
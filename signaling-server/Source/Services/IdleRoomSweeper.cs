@@ -0,0 +1,91 @@
+using System.Net.WebSockets;
+using SignalingServer.Extensions;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Periodically closes rooms whose host and clients have sent nothing for longer than the idle
+/// timeout. Members get a "room-expired" close frame and are removed from the registry.
+/// </summary>
+public class IdleRoomSweeper(
+    ISignalRegistry signalRegistry,
+    TimeSpan idleTimeout,
+    TimeSpan sweepInterval,
+    TimeProvider timeProvider,
+    ILogger<IdleRoomSweeper> logger
+) : BackgroundService
+{
+    public const string RoomExpiredReason = "room-expired";
+
+    private static readonly TimeSpan CloseTimeout = TimeSpan.FromSeconds(5);
+
+    protected override async Task ExecuteAsync(CancellationToken stoppingToken)
+    {
+        if (idleTimeout <= TimeSpan.Zero)
+        {
+            logger.LogDebug("Idle room expiry disabled");
+            return;
+        }
+
+        using var timer = new PeriodicTimer(sweepInterval, timeProvider);
+        try
+        {
+            while (await timer.WaitForNextTickAsync(stoppingToken))
+            {
+                await SweepAsync();
+            }
+        }
+        catch (OperationCanceledException)
+        {
+            // Host is stopping
+        }
+    }
+
+    /// <summary>
+    /// Closes every room that has been idle for longer than the timeout.
+    /// </summary>
+    /// <returns>The number of rooms closed.</returns>
+    public async Task<int> SweepAsync()
+    {
+        // Work from a snapshot and hold no registry lock while closing sockets, so joins and
+        // leaves racing with the sweep are never blocked behind network I/O
+        var idleHosts = signalRegistry.GetIdleHosts(idleTimeout);
+
+        foreach (var hostId in idleHosts)
+        {
+            await ExpireRoom(hostId);
+        }
+
+        return idleHosts.Count;
+    }
+
+    private async Task ExpireRoom(string hostId)
+    {
+        var clients = signalRegistry.GetClientsForHost(hostId).ToList();
+        logger.LogInformation(
+            "Closing idle host {HostId} with {Count} clients",
+            hostId,
+            clients.Count
+        );
+
+        foreach (var client in clients)
+        {
+            signalRegistry.RemoveClient(client);
+            await client.CloseOutputOrAbortAsync(
+                WebSocketCloseStatus.NormalClosure,
+                RoomExpiredReason,
+                CloseTimeout
+            );
+        }
+
+        if (signalRegistry.TryGetHostSocket(hostId, out var hostSocket))
+        {
+            signalRegistry.RemoveHost(hostId);
+            await hostSocket.CloseOutputOrAbortAsync(
+                WebSocketCloseStatus.NormalClosure,
+                RoomExpiredReason,
+                CloseTimeout
+            );
+        }
+    }
+}
@@ -0,0 +1,183 @@
+using System.Security.Claims;
+using System.Text;
+using Microsoft.IdentityModel.JsonWebTokens;
+using Microsoft.IdentityModel.Tokens;
+using SignalingServer.Configuration;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Validates bearer tokens presented on the WebSocket upgrade.
+/// Tokens are signed with the shared HMAC secret or with a key from the configured JWKS
+/// endpoint; JWKS keys are cached and refreshed after
+/// <see cref="JwtAuthOptions.JwksRefreshInterval"/>, or sooner when a token names a key id
+/// that isn't cached yet.
+/// </summary>
+public class JwtAuthenticator(
+    JwtAuthOptions options,
+    HttpClient httpClient,
+    TimeProvider timeProvider,
+    ILogger<JwtAuthenticator> logger
+)
+{
+    /// <summary>
+    /// Claim listing the host ids the token holder may join.
+    /// </summary>
+    public const string RoomsClaim = "rooms";
+
+    /// <summary>
+    /// Claim listing the message types the token holder may send; without it, any type.
+    /// </summary>
+    public const string ActionsClaim = "actions";
+
+    // Minimum time between JWKS requests, so a failing endpoint or tokens with made-up key ids
+    // can't trigger a request per connection
+    private static readonly TimeSpan JwksRetryBackoff = TimeSpan.FromSeconds(10);
+
+    private readonly JsonWebTokenHandler _tokenHandler = new();
+    private readonly SemaphoreSlim _jwksRefreshLock = new(1, 1);
+    private IReadOnlyList<SecurityKey> _jwksKeys = [];
+    private DateTimeOffset _jwksFetchedAt = DateTimeOffset.MinValue;
+    private DateTimeOffset _jwksAttemptedAt = DateTimeOffset.MinValue;
+
+    public bool IsEnabled => options.IsEnabled;
+
+    /// <summary>
+    /// Validates the token's signature, expiry and, if configured, issuer and audience.
+    /// </summary>
+    /// <returns>The token's identity, or null if the token is missing or invalid.</returns>
+    public async Task<ClaimsIdentity?> AuthenticateAsync(
+        string? token,
+        CancellationToken cancellationToken
+    )
+    {
+        if (string.IsNullOrEmpty(token))
+            return null;
+
+        var keys = await GetSigningKeysAsync(refreshJwks: false, cancellationToken);
+        var result = await ValidateAsync(token, keys);
+
+        // The issuer may have rotated in a key since the JWKS was cached
+        if (!result.IsValid && options.JwksUrl != null && HasUnknownKeyId(token, keys))
+        {
+            keys = await GetSigningKeysAsync(refreshJwks: true, cancellationToken);
+            result = await ValidateAsync(token, keys);
+        }
+
+        if (!result.IsValid)
+        {
+            logger.LogInformation(result.Exception, "Bearer token rejected");
+            return null;
+        }
+
+        return result.ClaimsIdentity;
+    }
+
+    /// <summary>
+    /// Returns the host ids listed in the identity's <see cref="RoomsClaim"/>, or null if the
+    /// token has no such claim and may join any host.
+    /// </summary>
+    public static IReadOnlySet<string>? GetAllowedHosts(ClaimsIdentity identity)
+    {
+        var rooms = identity.FindAll(RoomsClaim).Select(claim => claim.Value).ToList();
+        return rooms.Count == 0 ? null : rooms.ToHashSet();
+    }
+
+    public static IReadOnlySet<string>? GetAllowedActions(ClaimsIdentity identity)
+    {
+        var actions = identity.FindAll(ActionsClaim).Select(claim => claim.Value).ToList();
+        return actions.Count == 0 ? null : actions.ToHashSet(StringComparer.OrdinalIgnoreCase);
+    }
+
+    private Task<TokenValidationResult> ValidateAsync(string token, List<SecurityKey> keys)
+    {
+        var parameters = new TokenValidationParameters
+        {
+            IssuerSigningKeys = keys,
+            ValidateIssuer = options.Issuer != null,
+            ValidIssuer = options.Issuer,
+            ValidateAudience = options.Audience != null,
+            ValidAudience = options.Audience,
+            ValidateLifetime = true,
+            RequireExpirationTime = true,
+            ClockSkew = TimeSpan.FromSeconds(30),
+        };
+
+        return _tokenHandler.ValidateTokenAsync(token, parameters);
+    }
+
+    private bool HasUnknownKeyId(string token, List<SecurityKey> keys)
+    {
+        if (!_tokenHandler.CanReadToken(token))
+            return false;
+
+        var keyId = _tokenHandler.ReadJsonWebToken(token).Kid;
+        return !string.IsNullOrEmpty(keyId) && keys.All(key => key.KeyId != keyId);
+    }
+
+    private async Task<List<SecurityKey>> GetSigningKeysAsync(
+        bool refreshJwks,
+        CancellationToken cancellationToken
+    )
+    {
+        var keys = new List<SecurityKey>();
+
+        if (options.HmacSecret != null)
+            keys.Add(new SymmetricSecurityKey(Encoding.UTF8.GetBytes(options.HmacSecret)));
+
+        if (options.JwksUrl != null)
+        {
+            keys.AddRange(await GetJwksKeysAsync(options.JwksUrl, refreshJwks, cancellationToken));
+        }
+
+        return keys;
+    }
+
+    private async Task<IReadOnlyList<SecurityKey>> GetJwksKeysAsync(
+        string jwksUrl,
+        bool forceRefresh,
+        CancellationToken cancellationToken
+    )
+    {
+        if (!IsJwksFetchDue(forceRefresh))
+            return _jwksKeys;
+
+        await _jwksRefreshLock.WaitAsync(cancellationToken);
+        try
+        {
+            // Another connection may have refreshed the keys while we waited
+            if (!IsJwksFetchDue(forceRefresh))
+                return _jwksKeys;
+
+            _jwksAttemptedAt = timeProvider.GetUtcNow();
+            var json = await httpClient.GetStringAsync(jwksUrl, cancellationToken);
+            _jwksKeys = new JsonWebKeySet(json).GetSigningKeys().ToList();
+            _jwksFetchedAt = timeProvider.GetUtcNow();
+            logger.LogInformation("Fetched {Count} signing keys from JWKS", _jwksKeys.Count);
+        }
+        catch (Exception ex)
+            when (ex is HttpRequestException or ArgumentException
+                || (ex is TaskCanceledException && !cancellationToken.IsCancellationRequested)
+            )
+        {
+            // Keep serving the previous keys rather than rejecting every connection, and try
+            // again once the backoff has passed
+            logger.LogWarning(ex, "Failed to refresh JWKS from {JwksUrl}", jwksUrl);
+        }
+        finally
+        {
+            _jwksRefreshLock.Release();
+        }
+
+        return _jwksKeys;
+    }
+
+    private bool IsJwksFetchDue(bool forceRefresh)
+    {
+        var now = timeProvider.GetUtcNow();
+        if (now - _jwksAttemptedAt < JwksRetryBackoff)
+            return false;
+
+        return forceRefresh || now - _jwksFetchedAt >= options.JwksRefreshInterval;
+    }
+}
@@ -0,0 +1,67 @@
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Refuses new connections under overload while leaving existing ones alone. Shedding starts once
+/// active connections exceed the high-water mark and only stops when they drop below the
+/// low-water mark, so the server doesn't flap around a single threshold.
+/// </summary>
+public class LoadShedder
+{
+    private int _shedding;
+
+    /// <param name="highWaterMark">Connection count above which new upgrades are shed; zero or
+    /// less disables shedding.</param>
+    /// <param name="lowWaterMark">Connection count below which upgrades are accepted again.</param>
+    public LoadShedder(int highWaterMark, int lowWaterMark)
+    {
+        if (highWaterMark > 0 && (lowWaterMark <= 0 || lowWaterMark >= highWaterMark))
+        {
+            throw new ArgumentOutOfRangeException(
+                nameof(lowWaterMark),
+                lowWaterMark,
+                $"Low-water mark must be above 0 and below the high-water mark ({highWaterMark})"
+            );
+        }
+
+        HighWaterMark = Math.Max(highWaterMark, 0);
+        LowWaterMark = HighWaterMark > 0 ? lowWaterMark : 0;
+        SignalingMetrics.LoadSheddingHighWaterMark.Set(HighWaterMark);
+        SignalingMetrics.LoadSheddingLowWaterMark.Set(LowWaterMark);
+        SignalingMetrics.LoadShedding.Set(0);
+    }
+
+    public int HighWaterMark { get; }
+    public int LowWaterMark { get; }
+
+    public bool IsShedding => Volatile.Read(ref _shedding) == 1;
+
+    public static LoadShedder FromEnvironment() =>
+        new(
+            int.Parse(Environment.GetEnvironmentVariable("LOAD_SHED_HIGH_WATER_MARK") ?? "0"),
+            int.Parse(Environment.GetEnvironmentVariable("LOAD_SHED_LOW_WATER_MARK") ?? "0")
+        );
+
+    /// <summary>
+    /// Moves between accepting and shedding based on the current connection count.
+    /// </summary>
+    public void Update(int activeConnections)
+    {
+        if (HighWaterMark == 0)
+            return;
+
+        if (activeConnections > HighWaterMark)
+        {
+            SetShedding(true);
+        }
+        else if (activeConnections < LowWaterMark)
+        {
+            SetShedding(false);
+        }
+    }
+
+    private void SetShedding(bool shedding)
+    {
+        Volatile.Write(ref _shedding, shedding ? 1 : 0);
+        SignalingMetrics.LoadShedding.Set(shedding ? 1 : 0);
+    }
+}
@@ -0,0 +1,121 @@
+using System.Collections.Concurrent;
+using System.Diagnostics;
+using System.Diagnostics.CodeAnalysis;
+using System.Net.WebSockets;
+using System.Security.Cryptography;
+using SignalingServer.Configuration;
+using SignalingServer.Helpers;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Open long-poll sessions by id. Each session is a <see cref="PollingWebSocket"/> driven by
+/// the regular connection handler, so it hosts, joins and relays exactly like a WebSocket peer.
+/// Sessions that stop polling are aborted once they have been idle for the session timeout.
+/// </summary>
+public class LongPollSessions(
+    IConnectionHandler connectionHandler,
+    ConnectionLimiter connectionLimiter,
+    LongPollOptions options,
+    TimeProvider timeProvider,
+    ILogger<LongPollSessions> logger
+) : BackgroundService
+{
+    private const int SessionIdBytes = 16;
+
+    private static readonly TimeSpan SweepInterval = TimeSpan.FromSeconds(10);
+
+    private readonly ConcurrentDictionary<string, PollingWebSocket> _sessions = new();
+
+    public LongPollOptions Options => options;
+
+    /// <summary>
+    /// Opens a session and starts handling it. The caller must already hold a slot from the
+    /// <see cref="ConnectionLimiter"/>; it is released when the session ends.
+    /// </summary>
+    /// <param name="parentContext">Trace context the session's connection span continues.</param>
+    public PollingWebSocket Open(ActivityContext parentContext = default)
+    {
+        var sessionId = Convert.ToHexStringLower(RandomNumberGenerator.GetBytes(SessionIdBytes));
+        var socket = new PollingWebSocket(sessionId, options.QueueSize, timeProvider);
+        _sessions[sessionId] = socket;
+
+        // Runs synchronously up to the first receive, so the socket is tracked on return
+        _ = HandleSessionAsync(socket, parentContext);
+
+        logger.LogDebug("Long-poll session {SessionId} opened", sessionId);
+        return socket;
+    }
+
+    public bool TryGet(string? sessionId, [NotNullWhen(true)] out PollingWebSocket? socket)
+    {
+        socket = null;
+        return sessionId != null && _sessions.TryGetValue(sessionId, out socket);
+    }
+
+    /// <summary>
+    /// Forgets a session. Its connection must already have been ended.
+    /// </summary>
+    public void Remove(string sessionId) => _sessions.TryRemove(sessionId, out _);
+
+    protected override async Task ExecuteAsync(CancellationToken stoppingToken)
+    {
+        using var timer = new PeriodicTimer(SweepInterval, timeProvider);
+        try
+        {
+            while (await timer.WaitForNextTickAsync(stoppingToken))
+            {
+                Sweep();
+            }
+        }
+        catch (OperationCanceledException)
+        {
+            // Host is stopping
+        }
+    }
+
+    /// <summary>
+    /// Aborts and forgets every session that has gone without polling for longer than the
+    /// session timeout, including ended sessions whose last messages were never collected.
+    /// </summary>
+    /// <returns>The number of sessions removed.</returns>
+    public int Sweep()
+    {
+        var now = timeProvider.GetUtcNow();
+        var removed = 0;
+
+        foreach (var (sessionId, socket) in _sessions)
+        {
+            if (now - socket.LastActivity <= options.SessionTimeout)
+                continue;
+
+            if (socket.State == WebSocketState.Open)
+            {
+                logger.LogInformation("Long-poll session {SessionId} timed out", sessionId);
+            }
+
+            // Fails the receive loop, which runs the normal disconnect cleanup
+            socket.Abort();
+            _sessions.TryRemove(sessionId, out _);
+            removed++;
+        }
+
+        return removed;
+    }
+
+    private async Task HandleSessionAsync(PollingWebSocket socket, ActivityContext parentContext)
+    {
+        try
+        {
+            await connectionHandler.HandleConnection(socket, CancellationToken.None, parentContext);
+        }
+        catch (Exception ex)
+        {
+            logger.LogError(ex, "Long-poll session {SessionId} failed", socket.SessionId);
+        }
+        finally
+        {
+            connectionLimiter.Release();
+        }
+    }
+}
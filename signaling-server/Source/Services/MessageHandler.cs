@@ -1,15 +1,41 @@
+using System.Diagnostics.CodeAnalysis;
 using System.Net.WebSockets;
+using System.Text;
 using System.Text.Json;
 using SignalingServer.Configuration;
 using SignalingServer.Extensions;
+using SignalingServer.Helpers;
+using SignalingServer.Middleware;
 using SignalingServer.Models;
 using SignalingServer.Validation;
 
 namespace SignalingServer.Services;
 
-public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandler> logger)
-    : IMessageHandler
+public class MessageHandler(
+    ISignalRegistry signalRegistry,
+    SessionTokenService sessionTokens,
+    ILogger<MessageHandler> logger,
+    IEnumerable<ISignalMiddleware>? middleware = null,
+    IRoomAuthorizer? authorizer = null,
+    RoomIdValidator? roomIdValidator = null
+) : IMessageHandler
 {
+    private static readonly int MaxMetadataBytes = int.Parse(
+        Environment.GetEnvironmentVariable("MAX_METADATA_BYTES") ?? "1024"
+    );
+
+    private static readonly TimeSpan CloseTimeout = TimeSpan.FromSeconds(5);
+
+    private readonly IRoomAuthorizer _authorizer = authorizer ?? new AllowAllAuthorizer();
+
+    private readonly RoomIdValidator _roomIdValidator = roomIdValidator ?? new RoomIdValidator();
+
+    private readonly ISignalMiddleware[] _middleware =
+    [
+        new SignalValidationMiddleware(logger),
+        .. middleware ?? [],
+    ];
+
     public async Task HandleMessage(WebSocket socket, string raw)
     {
         SignalMessage? msg;
@@ -21,44 +47,62 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
             if (msg == null)
             {
                 logger.LogWarning("Invalid message format");
-                await socket.SendErrorAsync("Invalid message format");
-                return;
-            }
-
-            var validator = new SignalMessageValidator();
-            var validationResult = await validator.ValidateAsync(msg);
-
-            if (!validationResult.IsValid)
-            {
-                logger.LogWarning(
-                    "Validation failed: {Errors}",
-                    string.Join("; ", validationResult.Errors.Select(e => e.ErrorMessage))
-                );
-                await socket.SendErrorAsync(
-                    "Validation failed: "
-                        + string.Join(", ", validationResult.Errors.Select(e => e.ErrorMessage))
-                );
+                await socket.SendErrorAsync("Invalid message format", SignalErrorCodes.BadMessage);
                 return;
             }
         }
         catch (JsonException je)
         {
             logger.LogWarning(je, "Malformed JSON or unknown message type");
-            await socket.SendErrorAsync("Malformed JSON or unknown message type");
+            await socket.SendErrorAsync(
+                "Malformed JSON or unknown message type",
+                SignalErrorCodes.BadMessage
+            );
             return;
         }
 
+        // Types are matched case-insensitively; normalize once so validation, middleware and
+        // routing all see the same type
+        msg.Type = msg.Type?.ToLowerInvariant();
+
+        await RunPipeline(
+            new SignalContext(socket, msg, raw),
+            0,
+            context => RouteMessage(context.Socket, context.Message, context.Raw)
+        );
+    }
+
+    // Validation runs first, then the configured middleware in order, then routing
+    private Task RunPipeline(SignalContext context, int index, SignalDelegate route) =>
+        index < _middleware.Length
+            ? _middleware[index].InvokeAsync(context, next => RunPipeline(next, index + 1, route))
+            : route(context);
+
+    private async Task RouteMessage(WebSocket socket, SignalMessage msg, string raw)
+    {
+        using var activity = SignalingTracing.StartMessage(msg.Type!);
+        SignalingMetrics.RecordMessage(msg.Type!, Encoding.UTF8.GetByteCount(raw));
+        signalRegistry.RecordActivity(socket);
+
         string? hostId;
         string? clientId;
         WebSocket? hostSocket;
 
-        switch (msg.Type!.ToLower())
+        switch (msg.Type)
         {
             case SignalMessageTypes.Host:
-                hostId = await signalRegistry.GenerateUniqueHostIdAsync();
                 var maxClients = msg.MaxClients ?? 10; // Default to 10 if not specified
 
-                signalRegistry.RegisterHost(hostId, socket, maxClients);
+                // Reclaim the previous host id if the token is valid and the id is still free
+                if (
+                    !TryReclaimId(socket, msg.ReconnectToken, PeerRole.Host, null, out hostId)
+                    || !signalRegistry.RegisterHost(hostId, socket, maxClients, msg.JoinSecret)
+                )
+                {
+                    hostId = await signalRegistry.GenerateUniqueHostIdAsync();
+                    signalRegistry.RegisterHost(hostId, socket, maxClients, msg.JoinSecret);
+                }
+
                 logger.LogInformation(
                     "Host registered: {HostId} with maxClients: {MaxClients}",
                     hostId,
@@ -71,6 +115,12 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                         Type = SignalMessageTypes.Host,
                         HostId = hostId,
                         RequestId = msg.RequestId,
+                        ReconnectToken = sessionTokens.Issue(
+                            PeerRole.Host,
+                            hostId,
+                            hostId,
+                            signalRegistry.GetNamespace(socket)
+                        ),
                     }
                 );
 
@@ -80,17 +130,134 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                 if (string.IsNullOrWhiteSpace(msg.HostId))
                 {
                     logger.LogWarning("Tried to join a host without a valid id");
-                    await socket.SendErrorAsync("Missing hostId");
+                    await socket.SendErrorAsync(
+                        "Missing hostId",
+                        SignalErrorCodes.BadMessage,
+                        msg.RequestId
+                    );
+                    return;
+                }
+
+                // Checked before anything else so a malformed id is never logged or looked up
+                if (!_roomIdValidator.IsValid(msg.HostId))
+                {
+                    logger.LogWarning(
+                        "Join rejected - hostId of {Length} characters is not a valid room id",
+                        msg.HostId.Length
+                    );
+                    await socket.SendErrorAsync(
+                        "Invalid hostId",
+                        SignalErrorCodes.InvalidRoomId,
+                        msg.RequestId
+                    );
+                    return;
+                }
+
+                if (!_authorizer.CanJoin(socket, msg.HostId))
+                {
+                    logger.LogWarning("Not authorized to join host {HostId}", msg.HostId);
+                    await socket.SendErrorAsync(
+                        $"Not allowed to join host {msg.HostId}",
+                        SignalErrorCodes.Forbidden,
+                        msg.RequestId
+                    );
+                    await socket.CloseOutputOrAbortAsync(
+                        WebSocketCloseStatus.PolicyViolation,
+                        SignalErrorCodes.Forbidden,
+                        CloseTimeout
+                    );
+                    return;
+                }
+
+                // Rooms of other namespaces are reported as missing so their ids don't leak
+                if (
+                    signalRegistry.TryGetHostSocket(msg.HostId, out hostSocket)
+                    && !IsInSameNamespace(socket, hostSocket)
+                )
+                {
+                    logger.LogWarning(
+                        "Join rejected - host {HostId} is in another namespace",
+                        msg.HostId
+                    );
+                    await socket.SendErrorAsync(
+                        $"Host {msg.HostId} not found",
+                        SignalErrorCodes.HostNotFound,
+                        msg.RequestId
+                    );
+                    return;
+                }
+
+                if (signalRegistry.IsMigrating(msg.HostId))
+                {
+                    logger.LogInformation("Join rejected - host {HostId} is migrating", msg.HostId);
+                    await socket.SendErrorAsync(
+                        $"Host {msg.HostId} is migrating",
+                        SignalErrorCodes.Migrating,
+                        msg.RequestId
+                    );
+                    return;
+                }
+
+                if (IsMetadataTooLarge(msg.Metadata))
+                {
+                    logger.LogWarning(
+                        "Join rejected - metadata exceeds {MaxBytes} bytes",
+                        MaxMetadataBytes
+                    );
+                    await socket.SendErrorAsync(
+                        $"Metadata exceeds {MaxMetadataBytes} bytes",
+                        SignalErrorCodes.MetadataTooLarge,
+                        msg.RequestId
+                    );
                     return;
                 }
 
                 if (signalRegistry.TryGetHostSocket(msg.HostId, out hostSocket))
                 {
-                    clientId = await signalRegistry.GenerateUniqueClientIdAsync();
+                    if (!signalRegistry.IsJoinSecretValid(msg.HostId, msg.JoinSecret))
+                    {
+                        logger.LogWarning(
+                            "Join rejected - wrong or missing secret for host {HostId}",
+                            msg.HostId
+                        );
+                        await socket.SendErrorAsync(
+                            "Wrong or missing join secret",
+                            SignalErrorCodes.BadSecret,
+                            msg.RequestId
+                        );
+                        return;
+                    }
+
+                    if (
+                        TryReclaimId(
+                            socket,
+                            msg.ReconnectToken,
+                            PeerRole.Client,
+                            msg.HostId,
+                            out clientId
+                        )
+                        && signalRegistry.ReplaceClient(
+                            clientId,
+                            socket,
+                            msg.HostId,
+                            out var previousSocket
+                        )
+                    )
+                    {
+                        await ReplaceConnection(previousSocket, clientId, msg, socket);
+                        break;
+                    }
+
+                    if (clientId == null || signalRegistry.TryGetClientSocket(clientId, out _))
+                    {
+                        clientId = await signalRegistry.GenerateUniqueClientIdAsync();
+                    }
+
                     var clientRegistered = signalRegistry.RegisterClient(
                         clientId,
                         socket,
-                        msg.HostId
+                        msg.HostId,
+                        out var existingClientIds
                     );
 
                     if (!clientRegistered)
@@ -100,17 +267,24 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                             clientId,
                             msg.HostId
                         );
-                        await socket.SendJsonAsync(
-                            new SignalMessage
-                            {
-                                Type = SignalMessageTypes.Error,
-                                Payload = "Host is at capacity",
-                                RequestId = msg.RequestId,
-                            }
+                        await socket.SendErrorAsync(
+                            "Host is at capacity",
+                            SignalErrorCodes.RoomFull,
+                            msg.RequestId
+                        );
+                        await socket.CloseOutputOrAbortAsync(
+                            WebSocketCloseStatus.PolicyViolation,
+                            SignalErrorCodes.RoomFull,
+                            CloseTimeout
                         );
                         return;
                     }
 
+                    if (msg.Metadata.HasValue)
+                    {
+                        signalRegistry.SetClientMetadata(socket, msg.Metadata.Value);
+                    }
+
                     logger.LogInformation(
                         "Client {ClientId} joined host {HostId}",
                         clientId,
@@ -125,6 +299,24 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                             HostId = msg.HostId,
                             ClientId = clientId,
                             RequestId = msg.RequestId,
+                            ReconnectToken = sessionTokens.Issue(
+                                PeerRole.Client,
+                                clientId,
+                                msg.HostId,
+                                signalRegistry.GetNamespace(socket)
+                            ),
+                            ShouldOffer = NegotiationCoordinator.ShouldOffer(clientId, msg.HostId),
+                        }
+                    );
+
+                    // Tell the new client who is already in the room before anyone learns of it
+                    await socket.SendJsonAsync(
+                        new SignalMessage
+                        {
+                            Type = SignalMessageTypes.RoomState,
+                            HostId = msg.HostId,
+                            ClientIds = existingClientIds,
+                            PeerMetadata = GetMetadata(existingClientIds),
                         }
                     );
 
@@ -138,6 +330,11 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                                 HostId = msg.HostId,
                                 ClientId = clientId,
                                 RequestId = msg.RequestId,
+                                Metadata = msg.Metadata,
+                                ShouldOffer = NegotiationCoordinator.ShouldOffer(
+                                    msg.HostId,
+                                    clientId
+                                ),
                             }
                         );
                     }
@@ -154,7 +351,11 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                 else
                 {
                     logger.LogWarning("Host for {hostId} not found", msg.HostId);
-                    await socket.SendErrorAsync($"Host {msg.HostId} not found");
+                    await socket.SendErrorAsync(
+                        $"Host {msg.HostId} not found",
+                        SignalErrorCodes.HostNotFound,
+                        msg.RequestId
+                    );
                 }
 
                 break;
@@ -162,10 +363,21 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
             case SignalMessageTypes.MsgToHost:
                 if (
                     signalRegistry.TryGetClientHost(socket, out hostId)
-                    && signalRegistry.TryGetHostSocket(hostId, out hostSocket)
                     && signalRegistry.TryGetClientId(socket, out clientId)
                 )
                 {
+                    activity?.SetTag(SignalingTracing.TargetPeerAttribute, hostId);
+                    if (!signalRegistry.TryGetHostSocket(hostId, out hostSocket))
+                    {
+                        logger.LogWarning("Host {HostId} not available", hostId);
+                        await ReportPeerUnavailable(
+                            socket,
+                            msg.RequestId,
+                            $"Host {hostId} not available"
+                        );
+                        return;
+                    }
+
                     logger.LogInformation(
                         "Client {ClientId} → Host [{HostId}] [{MessageType}] {Payload}",
                         clientId,
@@ -183,11 +395,16 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                         RequestId = msg.RequestId,
                     };
 
-                    await hostSocket.SendJsonAsync(forward);
+                    var failure = await DeliverAsync(hostSocket, forward);
+                    await SendDeliveryReport(socket, msg.RequestId, failure);
                 }
                 else
                 {
-                    await socket.SendErrorAsync("Not connected to a host or unregistered client");
+                    await socket.SendErrorAsync(
+                        "Not connected to a host or unregistered client",
+                        SignalErrorCodes.NotRegistered,
+                        msg.RequestId
+                    );
                 }
 
                 break;
@@ -198,7 +415,14 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                     && !string.IsNullOrWhiteSpace(msg.ClientId)
                 )
                 {
-                    if (signalRegistry.TryGetClientSocket(msg.ClientId, out var clientSocket))
+                    activity?.SetTag(SignalingTracing.TargetPeerAttribute, msg.ClientId);
+
+                    // Hosts may only reach their own clients, which also keeps namespaces apart
+                    if (
+                        signalRegistry.TryGetClientSocket(msg.ClientId, out var clientSocket)
+                        && signalRegistry.TryGetClientHost(clientSocket, out var clientHostId)
+                        && clientHostId == hostId
+                    )
                     {
                         logger.LogInformation(
                             "Host {HostId} → Client {ClientId} [{MessageType}] {Payload}",
@@ -217,29 +441,398 @@ public class MessageHandler(ISignalRegistry signalRegistry, ILogger<MessageHandl
                             RequestId = msg.RequestId,
                         };
 
-                        await clientSocket.SendJsonAsync(forward);
+                        var failure = await DeliverAsync(clientSocket, forward);
+                        await SendDeliveryReport(socket, msg.RequestId, failure);
                     }
                     else
                     {
                         logger.LogWarning("Client {ClientId} not found", msg.ClientId);
-                        await socket.SendErrorAsync($"Client {msg.ClientId} not found");
+                        await ReportPeerUnavailable(
+                            socket,
+                            msg.RequestId,
+                            $"Client {msg.ClientId} not found"
+                        );
                     }
                 }
                 else
                 {
                     logger.LogWarning("Not registered as host or missing clientId");
-                    await socket.SendErrorAsync("Not registered as host or missing clientId");
+                    await socket.SendErrorAsync(
+                        "Not registered as host or missing clientId",
+                        SignalErrorCodes.NotRegistered,
+                        msg.RequestId
+                    );
                 }
 
                 break;
 
+            case SignalMessageTypes.SetMetadata:
+                if (
+                    !signalRegistry.TryGetClientId(socket, out clientId)
+                    || !signalRegistry.TryGetClientHost(socket, out hostId)
+                )
+                {
+                    await socket.SendErrorAsync(
+                        "Not connected to a host or unregistered client",
+                        SignalErrorCodes.NotRegistered,
+                        msg.RequestId
+                    );
+                    break;
+                }
+
+                // Validation requires metadata, but never let a bad message reach .Value
+                if (!msg.Metadata.HasValue)
+                {
+                    await socket.SendErrorAsync(
+                        "Metadata is required",
+                        SignalErrorCodes.BadMessage,
+                        msg.RequestId
+                    );
+                    break;
+                }
+
+                if (IsMetadataTooLarge(msg.Metadata))
+                {
+                    await socket.SendErrorAsync(
+                        $"Metadata exceeds {MaxMetadataBytes} bytes",
+                        SignalErrorCodes.MetadataTooLarge,
+                        msg.RequestId
+                    );
+                    break;
+                }
+
+                signalRegistry.SetClientMetadata(socket, msg.Metadata.Value);
+                logger.LogInformation("Client {ClientId} updated its metadata", clientId);
+
+                await BroadcastToRoom(
+                    hostId,
+                    socket,
+                    new SignalMessage
+                    {
+                        Type = SignalMessageTypes.PeerUpdated,
+                        HostId = hostId,
+                        ClientId = clientId,
+                        Metadata = msg.Metadata,
+                    }
+                );
+
+                break;
+
             default:
                 logger.LogWarning("Received unknown message type: {Type}", msg.Type);
-                await socket.SendErrorAsync("Unknown message type");
+                await socket.SendErrorAsync(
+                    "Unknown message type",
+                    SignalErrorCodes.UnknownType,
+                    msg.RequestId,
+                    msg.Type
+                );
                 break;
         }
     }
 
+    /// <summary>
+    /// Relays a binary frame between a host and one of its clients. The payload is forwarded
+    /// untouched; only the header is rewritten from the target's id to the sender's.
+    /// </summary>
+    public async Task HandleBinaryMessage(WebSocket socket, byte[] frame)
+    {
+        if (!BinaryEnvelope.TryParse(frame, out var targetId, out var payload))
+        {
+            logger.LogWarning("Malformed binary frame of {Length} bytes", frame.Length);
+            await socket.SendErrorAsync("Malformed binary frame", SignalErrorCodes.BadMessage);
+            return;
+        }
+
+        using var activity = SignalingTracing.StartMessage(SignalingMetrics.BinaryType);
+        activity?.SetTag(SignalingTracing.TargetPeerAttribute, targetId);
+        SignalingMetrics.RecordMessage(SignalingMetrics.BinaryType, frame.Length);
+        signalRegistry.RecordActivity(socket);
+
+        string senderId;
+        string relayType;
+        WebSocket? target = null;
+
+        if (signalRegistry.TryGetHostId(socket, out var hostId))
+        {
+            // Hosts may only reach their own clients
+            senderId = hostId;
+            relayType = SignalMessageTypes.MsgToClient;
+            if (
+                signalRegistry.TryGetClientSocket(targetId, out var clientSocket)
+                && signalRegistry.TryGetClientHost(clientSocket, out var clientHostId)
+                && clientHostId == hostId
+            )
+            {
+                target = clientSocket;
+            }
+        }
+        else if (
+            signalRegistry.TryGetClientId(socket, out var clientId)
+            && signalRegistry.TryGetClientHost(socket, out hostId)
+        )
+        {
+            // Clients may only reach their host
+            senderId = clientId;
+            relayType = SignalMessageTypes.MsgToHost;
+            if (targetId == hostId && signalRegistry.TryGetHostSocket(hostId, out var hostSocket))
+            {
+                target = hostSocket;
+            }
+        }
+        else
+        {
+            await socket.SendErrorAsync(
+                "Not connected to a host or unregistered client",
+                SignalErrorCodes.NotRegistered
+            );
+            return;
+        }
+
+        // A binary frame is authorized as the relay message it stands in for. There is no JSON
+        // to validate, so the pipeline starts after the validation step.
+        var relay = new SignalMessage
+        {
+            Type = relayType,
+            HostId = relayType == SignalMessageTypes.MsgToHost ? targetId : null,
+            ClientId = relayType == SignalMessageTypes.MsgToClient ? targetId : null,
+        };
+        await RunPipeline(
+            new SignalContext(socket, relay, $"[binary] {payload.Length} bytes"),
+            1,
+            _ => RelayBinary(socket, senderId, targetId, target, payload)
+        );
+    }
+
+    private async Task RelayBinary(
+        WebSocket socket,
+        string senderId,
+        string targetId,
+        WebSocket? target,
+        ReadOnlyMemory<byte> payload
+    )
+    {
+        if (target == null)
+        {
+            logger.LogWarning("Binary frame target {TargetId} not available", targetId);
+            await socket.SendErrorAsync(
+                $"Peer {targetId} not available",
+                SignalErrorCodes.PeerUnavailable
+            );
+            return;
+        }
+
+        logger.LogDebug(
+            "{SenderId} → {TargetId} [binary] {Length} bytes",
+            senderId,
+            targetId,
+            payload.Length
+        );
+        await target.SendBinaryAsync(BinaryEnvelope.Create(senderId, payload.Span));
+    }
+
+    /// <summary>
+    /// Completes a join that took over a client id still held by an older connection:
+    /// the old socket is closed with a "replaced" reason and the new one is acknowledged,
+    /// without the host seeing the client leave.
+    /// </summary>
+    private async Task ReplaceConnection(
+        WebSocket previousSocket,
+        string clientId,
+        SignalMessage msg,
+        WebSocket socket
+    )
+    {
+        var hostId = msg.HostId!;
+        logger.LogInformation(
+            "Client {ClientId} reconnected to host {HostId}, replacing its previous connection",
+            clientId,
+            hostId
+        );
+
+        if (msg.Metadata.HasValue)
+        {
+            signalRegistry.SetClientMetadata(socket, msg.Metadata.Value);
+        }
+
+        // A stalled old connection is aborted rather than holding up the new one's ack
+        try
+        {
+            await previousSocket.CloseOutputOrAbortAsync(
+                WebSocketCloseStatus.PolicyViolation,
+                "replaced",
+                CloseTimeout
+            );
+        }
+        catch (ObjectDisposedException ex)
+        {
+            logger.LogDebug(ex, "Previous connection of client {ClientId} already gone", clientId);
+        }
+
+        await socket.SendJsonAsync(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = hostId,
+                ClientId = clientId,
+                RequestId = msg.RequestId,
+                ReconnectToken = sessionTokens.Issue(
+                    PeerRole.Client,
+                    clientId,
+                    hostId,
+                    signalRegistry.GetNamespace(socket)
+                ),
+                ShouldOffer = NegotiationCoordinator.ShouldOffer(clientId, hostId),
+            }
+        );
+
+        var otherClientIds = signalRegistry
+            .GetClientsForHost(hostId)
+            .Where(member => member != socket)
+            .Select(member => signalRegistry.TryGetClientId(member, out var id) ? id : null)
+            .OfType<string>()
+            .ToList();
+
+        await socket.SendJsonAsync(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.RoomState,
+                HostId = hostId,
+                ClientIds = otherClientIds,
+                PeerMetadata = GetMetadata(otherClientIds),
+            }
+        );
+    }
+
+    private bool IsInSameNamespace(WebSocket socket, WebSocket other) =>
+        signalRegistry.GetNamespace(socket) == signalRegistry.GetNamespace(other);
+
+    /// <summary>
+    /// Queues a relayed message for the target and returns why it could not be, if it wasn't.
+    /// A message only counts as delivered once it is in the target's outbound buffer.
+    /// </summary>
+    private static async Task<string?> DeliverAsync(WebSocket target, SignalMessage message)
+    {
+        if (target.State != WebSocketState.Open)
+            return SignalErrorCodes.PeerUnavailable;
+
+        await target.SendJsonAsync(message);
+
+        // A full buffer drops the target during the send instead of queueing the message
+        return target is IDroppableWebSocket { IsDropped: true }
+            ? SignalErrorCodes.SlowConsumer
+            : null;
+    }
+
+    /// <summary>
+    /// Acks or nacks a relayed message. Senders that didn't set a request id get no report.
+    /// </summary>
+    private static async Task SendDeliveryReport(
+        WebSocket sender,
+        string? requestId,
+        string? failure
+    )
+    {
+        if (requestId == null)
+            return;
+
+        await sender.SendJsonAsync(
+            new SignalMessage
+            {
+                Type = failure == null ? SignalMessageTypes.Ack : SignalMessageTypes.Nack,
+                RequestId = requestId,
+                Reason = failure,
+            }
+        );
+    }
+
+    // Senders tracking delivery get a nack they can match to the request; others an error
+    private static Task ReportPeerUnavailable(WebSocket sender, string? requestId, string error) =>
+        requestId == null
+            ? sender.SendErrorAsync(error, SignalErrorCodes.PeerUnavailable)
+            : SendDeliveryReport(sender, requestId, SignalErrorCodes.PeerUnavailable);
+
+    private static bool IsMetadataTooLarge(JsonElement? metadata) =>
+        metadata.HasValue
+        && Encoding.UTF8.GetByteCount(metadata.Value.GetRawText()) > MaxMetadataBytes;
+
+    private Dictionary<string, JsonElement> GetMetadata(IEnumerable<string> clientIds)
+    {
+        var metadata = new Dictionary<string, JsonElement>();
+        foreach (var id in clientIds)
+        {
+            if (signalRegistry.TryGetClientMetadata(id, out var clientMetadata))
+            {
+                metadata[id] = clientMetadata;
+            }
+        }
+        return metadata;
+    }
+
+    /// <summary>
+    /// Sends a message to the host and every client of the room except the sender.
+    /// Delivery failures are logged and don't stop the remaining sends.
+    /// </summary>
+    private async Task BroadcastToRoom(string hostId, WebSocket sender, SignalMessage message)
+    {
+        var recipients = signalRegistry.GetClientsForHost(hostId).Where(s => s != sender).ToList();
+        if (signalRegistry.TryGetHostSocket(hostId, out var hostSocket))
+        {
+            recipients.Add(hostSocket);
+        }
+
+        var tasks = recipients.Select(async recipient =>
+        {
+            try
+            {
+                await recipient.SendJsonAsync(message);
+            }
+            catch (Exception ex)
+            {
+                logger.LogWarning(
+                    ex,
+                    "Failed to deliver {MessageType} in room {HostId}",
+                    message.Type,
+                    hostId
+                );
+            }
+        });
+
+        await Task.WhenAll(tasks);
+    }
+
+    /// <summary>
+    /// Resolves the peer id carried by a reconnection token, if the token is authentic,
+    /// unexpired and issued for the given role (and host, for clients) in the namespace the
+    /// socket is connected to.
+    /// </summary>
+    private bool TryReclaimId(
+        WebSocket socket,
+        string? token,
+        PeerRole role,
+        string? expectedHostId,
+        [NotNullWhen(true)] out string? peerId
+    )
+    {
+        peerId = null;
+
+        if (string.IsNullOrEmpty(token))
+            return false;
+
+        if (
+            !sessionTokens.TryValidate(token, out var session)
+            || session.Role != role
+            || (expectedHostId != null && session.HostId != expectedHostId)
+            || session.Namespace != signalRegistry.GetNamespace(socket)
+        )
+        {
+            logger.LogInformation("Reconnect token rejected, issuing a new identity");
+            return false;
+        }
+
+        peerId = session.PeerId;
+        return true;
+    }
+
     /// <summary>
     /// Sends peer notifications when a socket disconnects.
     /// IMPORTANT: Call this BEFORE registry cleanup/closure so peers can still be notified.
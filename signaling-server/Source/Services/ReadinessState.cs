@@ -0,0 +1,22 @@
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Tracks whether this instance should receive new connections.
+/// Backs the <c>/readyz</c> endpoint so load balancers stop routing to a draining or full instance.
+/// </summary>
+public class ReadinessState(ISignalRegistry signalRegistry, int maxConnections = 0)
+{
+    private int _shuttingDown;
+
+    public bool IsShuttingDown => Volatile.Read(ref _shuttingDown) == 1;
+
+    /// <summary>
+    /// False once shutdown has begun, or while the open connection count is at the
+    /// configured maximum. A maximum of zero or less means unlimited.
+    /// </summary>
+    public bool IsReady =>
+        !IsShuttingDown
+        && (maxConnections <= 0 || signalRegistry.GetTrackedSockets().Count < maxConnections);
+
+    public void MarkShuttingDown() => Interlocked.Exchange(ref _shuttingDown, 1);
+}
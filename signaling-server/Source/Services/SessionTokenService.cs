@@ -0,0 +1,94 @@
+using System.Buffers.Text;
+using System.Diagnostics.CodeAnalysis;
+using System.Security.Cryptography;
+using System.Text;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Issues and validates HMAC-signed reconnection tokens, so a peer whose socket dropped can
+/// reclaim its previous id instead of being assigned a new one.
+/// Tokens have the form <c>base64url(payload).base64url(HMAC-SHA256(payload))</c>.
+/// </summary>
+public class SessionTokenService(byte[] key, TimeSpan lifetime, TimeProvider timeProvider)
+{
+    private const char Separator = '|';
+
+    /// <summary>
+    /// Creates a service configured from SESSION_TOKEN_SECRET and SESSION_TOKEN_TTL_SECONDS
+    /// (5 minutes by default). Without a secret a random per-process key is used, so tokens
+    /// are only honored by the instance that issued them.
+    /// </summary>
+    public static SessionTokenService FromEnvironment()
+    {
+        var secret = Environment.GetEnvironmentVariable("SESSION_TOKEN_SECRET");
+        var key = string.IsNullOrEmpty(secret)
+            ? RandomNumberGenerator.GetBytes(32)
+            : Encoding.UTF8.GetBytes(secret);
+        var ttlSeconds = int.Parse(
+            Environment.GetEnvironmentVariable("SESSION_TOKEN_TTL_SECONDS") ?? "300"
+        );
+
+        return new SessionTokenService(key, TimeSpan.FromSeconds(ttlSeconds), TimeProvider.System);
+    }
+
+    public string Issue(
+        PeerRole role,
+        string peerId,
+        string hostId,
+        string signalNamespace = SignalNamespaces.Default
+    )
+    {
+        var expiresAt = timeProvider.GetUtcNow().Add(lifetime).ToUnixTimeSeconds();
+        var fields = string.Join(Separator, role, peerId, hostId, signalNamespace, expiresAt);
+        var payload = Encoding.UTF8.GetBytes(fields);
+
+        return $"{Base64Url.EncodeToString(payload)}.{Base64Url.EncodeToString(Sign(payload))}";
+    }
+
+    /// <summary>
+    /// Validates a token's signature and expiry.
+    /// </summary>
+    /// <returns><c>true</c> if the token is authentic and unexpired; otherwise, <c>false</c>.</returns>
+    public bool TryValidate(string? token, [NotNullWhen(true)] out SessionToken? session)
+    {
+        session = null;
+
+        var parts = token?.Split('.');
+        if (parts is not { Length: 2 })
+            return false;
+
+        byte[] payload;
+        byte[] signature;
+        try
+        {
+            payload = Base64Url.DecodeFromChars(parts[0]);
+            signature = Base64Url.DecodeFromChars(parts[1]);
+        }
+        catch (FormatException)
+        {
+            return false;
+        }
+
+        if (!CryptographicOperations.FixedTimeEquals(signature, Sign(payload)))
+            return false;
+
+        var fields = Encoding.UTF8.GetString(payload).Split(Separator);
+        if (
+            fields.Length != 5
+            || !Enum.TryParse<PeerRole>(fields[0], out var role)
+            || !long.TryParse(fields[4], out var expiresAtSeconds)
+        )
+            return false;
+
+        var expiresAt = DateTimeOffset.FromUnixTimeSeconds(expiresAtSeconds);
+        if (expiresAt <= timeProvider.GetUtcNow())
+            return false;
+
+        session = new SessionToken(role, fields[1], fields[2], fields[3], expiresAt);
+        return true;
+    }
+
+    private byte[] Sign(byte[] payload) => HMACSHA256.HashData(key, payload);
+}
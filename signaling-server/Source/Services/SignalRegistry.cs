@@ -1,19 +1,37 @@
 using System.Collections.Concurrent;
 using System.Diagnostics.CodeAnalysis;
 using System.Net.WebSockets;
+using System.Text.Json;
 using NanoidDotNet;
 using SignalingServer.Helpers;
+using SignalingServer.Models;
 
 namespace SignalingServer.Services;
 
-public class SignalRegistry(ILogger<SignalRegistry> logger) : ISignalRegistry
+public class SignalRegistry(
+    ILogger<SignalRegistry> logger,
+    TimeProvider? timeProvider = null,
+    IPeerEventPublisher? peerEvents = null
+) : ISignalRegistry
 {
+    private readonly TimeProvider _timeProvider = timeProvider ?? TimeProvider.System;
     private readonly BiDirectionalConcurrentDictionary<string, WebSocket> _hosts = new();
     private readonly BiDirectionalConcurrentDictionary<string, WebSocket> _clients = new();
     private readonly ConcurrentDictionary<WebSocket, string> _clientHostMap = new();
-    private readonly ConcurrentDictionary<WebSocket, byte> _allSockets = new();
+    private readonly ConcurrentDictionary<WebSocket, TrackedSocket> _allSockets = new();
     private readonly ConcurrentDictionary<string, int> _hostMaxClients = new();
     private readonly ConcurrentDictionary<string, int> _hostClientCount = new();
+    private readonly ConcurrentDictionary<WebSocket, string> _namespaces = new();
+    private readonly ConcurrentDictionary<WebSocket, IReadOnlySet<string>> _allowedHosts = new();
+    private readonly ConcurrentDictionary<WebSocket, IReadOnlySet<string>> _allowedActions = new();
+    private readonly ConcurrentDictionary<WebSocket, JsonElement> _clientMetadata = new();
+    private readonly ConcurrentDictionary<string, byte> _migratingHosts = new();
+    private readonly ConcurrentDictionary<string, DateTimeOffset> _hostLastActivity = new();
+    private readonly ConcurrentDictionary<string, JoinSecret> _joinSecrets = new();
+    private readonly Lock _capacityLock = new();
+
+    // Serializes publishing and removing host ids with their join secrets
+    private readonly Lock _hostLock = new();
     private const string IdAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789";
 
     public async Task<string> GenerateUniqueHostIdAsync()
@@ -36,13 +54,38 @@ public class SignalRegistry(ILogger<SignalRegistry> logger) : ISignalRegistry
         return id;
     }
 
-    public bool RegisterHost(string hostId, WebSocket socket, int maxClients = 10)
+    public bool RegisterHost(
+        string hostId,
+        WebSocket socket,
+        int maxClients = 10,
+        string? joinSecret = null
+    )
     {
-        var success = _hosts.TryAdd(hostId, socket);
+        // Hashing is slow, so it happens before taking the lock
+        var secret = string.IsNullOrEmpty(joinSecret) ? null : JoinSecret.Create(joinSecret);
+
+        bool success;
+        lock (_hostLock)
+        {
+            // The secret is in place before the id is published, so a joiner can never see
+            // the room unprotected
+            success = !_hosts.ContainsKey(hostId);
+            if (success)
+            {
+                if (secret != null)
+                    _joinSecrets[hostId] = secret;
+
+                _hosts.TryAdd(hostId, socket);
+            }
+        }
+
         if (success)
         {
             _hostMaxClients.TryAdd(hostId, maxClients);
             _hostClientCount.TryAdd(hostId, 0);
+            _hostLastActivity[hostId] = _timeProvider.GetUtcNow();
+            SignalingMetrics.ActiveRooms.Inc();
+            PublishRoomEvent(PeerEventTypes.RoomJoined, socket, hostId, hostId, PeerRole.Host);
         }
         return success;
     }
@@ -53,33 +96,97 @@ public class SignalRegistry(ILogger<SignalRegistry> logger) : ISignalRegistry
     public bool TryGetHostId(WebSocket socket, [NotNullWhen(true)] out string? hostId) =>
         _hosts.TryGetByValue(socket, out hostId);
 
-    public bool RegisterClient(string clientId, WebSocket socket, string hostId)
+    public bool RegisterClient(string clientId, WebSocket socket, string hostId) =>
+        RegisterClient(clientId, socket, hostId, out _);
+
+    public bool RegisterClient(
+        string clientId,
+        WebSocket socket,
+        string hostId,
+        out IReadOnlyList<string> existingClientIds
+    )
     {
-        // Check if host is at capacity
-        if (
-            _hostClientCount.TryGetValue(hostId, out var currentCount)
-            && _hostMaxClients.TryGetValue(hostId, out var maxClients)
-            && currentCount >= maxClients
-        )
+        existingClientIds = [];
+        bool added;
+
+        // The capacity check and the insertion must be atomic, otherwise concurrent joins
+        // can all observe a free slot and push the host past its limit
+        lock (_capacityLock)
         {
-            logger.LogWarning(
-                "Host {HostId} is at capacity ({CurrentCount}/{MaxClients})",
-                hostId,
-                currentCount,
-                maxClients
-            );
-            return false;
+            if (
+                _hostClientCount.TryGetValue(hostId, out var currentCount)
+                && _hostMaxClients.TryGetValue(hostId, out var maxClients)
+                && currentCount >= maxClients
+            )
+            {
+                logger.LogWarning(
+                    "Host {HostId} is at capacity ({CurrentCount}/{MaxClients})",
+                    hostId,
+                    currentCount,
+                    maxClients
+                );
+                return false;
+            }
+
+            // Snapshot the members under the same lock so a concurrent joiner is either
+            // listed here or will see this client in its own snapshot, never both or neither
+            var members = GetClientsForHost(hostId)
+                .Select(member => _clients.TryGetByValue(member, out var id) ? id : null)
+                .OfType<string>()
+                .ToList();
+
+            added = _clients.TryAdd(clientId, socket);
+            if (added)
+            {
+                existingClientIds = members;
+                _clientHostMap.TryAdd(socket, hostId);
+                _hostClientCount.AddOrUpdate(hostId, 1, (key, value) => value + 1);
+            }
         }
 
-        var added = _clients.TryAdd(clientId, socket);
         if (added)
         {
-            _clientHostMap.TryAdd(socket, hostId);
-            _hostClientCount.AddOrUpdate(hostId, 1, (key, value) => value + 1);
+            PublishRoomEvent(PeerEventTypes.RoomJoined, socket, hostId, clientId, PeerRole.Client);
         }
         return added;
     }
 
+    // Moves an existing client id onto a new socket without changing the host's member count,
+    // so a reconnect that beats the stale socket's cleanup doesn't look like a leave and a join
+    public bool ReplaceClient(
+        string clientId,
+        WebSocket socket,
+        string hostId,
+        [NotNullWhen(true)] out WebSocket? previousSocket
+    )
+    {
+        lock (_capacityLock)
+        {
+            if (
+                !_clients.TryGetByKey(clientId, out previousSocket)
+                || !_clientHostMap.TryGetValue(previousSocket, out var previousHostId)
+                || previousHostId != hostId
+            )
+            {
+                previousSocket = null;
+                return false;
+            }
+
+            _clients.TryRemoveByKey(clientId);
+            _clientHostMap.TryRemove(previousSocket, out _);
+
+            _clients.TryAdd(clientId, socket);
+            _clientHostMap.TryAdd(socket, hostId);
+
+            if (_clientMetadata.TryRemove(previousSocket, out var metadata))
+            {
+                _clientMetadata[socket] = metadata;
+            }
+
+            return true;
+        }
+    }
+
     public bool TryGetClientSocket(string clientId, [NotNullWhen(true)] out WebSocket? socket) =>
         _clients.TryGetByKey(clientId, out socket);
 
@@ -91,36 +198,132 @@ public class SignalRegistry(ILogger<SignalRegistry> logger) : ISignalRegistry
 
     public bool RemoveClient(WebSocket clientSocket)
     {
-        if (_clientHostMap.TryRemove(clientSocket, out var hostId))
+        string? hostId;
+        lock (_capacityLock)
         {
-            _hostClientCount.AddOrUpdate(hostId, 0, (key, value) => Math.Max(0, value - 1));
+            if (_clientHostMap.TryRemove(clientSocket, out hostId))
+            {
+                _hostClientCount.AddOrUpdate(hostId, 0, (key, value) => Math.Max(0, value - 1));
+            }
         }
         logger.LogDebug("ClientHostMap size = {Count}", _clientHostMap.Count);
+        _clientMetadata.TryRemove(clientSocket, out _);
+        if (_clients.TryGetByValue(clientSocket, out var clientId) && hostId != null)
+        {
+            PublishRoomEvent(
+                PeerEventTypes.RoomLeft,
+                clientSocket,
+                hostId,
+                clientId,
+                PeerRole.Client
+            );
+        }
         _clients.TryRemoveByValue(clientSocket);
         logger.LogDebug("Clients size = {Count}", _clients.Count);
         return true;
     }
 
+    public void SetClientMetadata(WebSocket clientSocket, JsonElement metadata)
+    {
+        _clientMetadata[clientSocket] = metadata;
+    }
+
+    public bool TryGetClientMetadata(string clientId, out JsonElement metadata)
+    {
+        metadata = default;
+        return _clients.TryGetByKey(clientId, out var socket)
+            && _clientMetadata.TryGetValue(socket, out metadata);
+    }
+
     public bool RemoveHost(string hostId)
     {
-        var success = _hosts.TryRemoveByKey(hostId);
+        _hosts.TryGetByKey(hostId, out var socket);
+        bool success;
+        lock (_hostLock)
+        {
+            success = _hosts.TryRemoveByKey(hostId);
+            if (success)
+                _joinSecrets.TryRemove(hostId, out _);
+        }
+
         if (success)
         {
             _hostMaxClients.TryRemove(hostId, out _);
             _hostClientCount.TryRemove(hostId, out _);
+            _migratingHosts.TryRemove(hostId, out _);
+            _hostLastActivity.TryRemove(hostId, out _);
+            SignalingMetrics.ActiveRooms.Dec();
+            PublishRoomEvent(PeerEventTypes.RoomLeft, socket, hostId, hostId, PeerRole.Host);
         }
         logger.LogDebug("Hosts size = {Count}", _hosts.Count);
         return success;
     }
 
+    public bool MarkMigrating(string hostId)
+    {
+        if (!_hosts.ContainsKey(hostId))
+            return false;
+
+        _migratingHosts.TryAdd(hostId, 0);
+        return true;
+    }
+
+    public bool IsMigrating(string hostId) => _migratingHosts.ContainsKey(hostId);
+
+    // Rooms without a secret admit anyone
+    public bool IsJoinSecretValid(string hostId, string? secret)
+    {
+        return !_joinSecrets.TryGetValue(hostId, out var joinSecret) || joinSecret.Matches(secret);
+    }
+
+    // A message from the host or any of its clients keeps the whole room alive
+    public void RecordActivity(WebSocket socket)
+    {
+        if (
+            _hosts.TryGetByValue(socket, out var hostId)
+            || _clientHostMap.TryGetValue(socket, out hostId)
+        )
+        {
+            _hostLastActivity[hostId] = _timeProvider.GetUtcNow();
+        }
+    }
+
+    public IReadOnlyList<string> GetIdleHosts(TimeSpan idleTimeout)
+    {
+        var cutoff = _timeProvider.GetUtcNow() - idleTimeout;
+        return _hostLastActivity
+            .Where(entry => entry.Value <= cutoff)
+            .Select(entry => entry.Key)
+            .ToList();
+    }
+
     public bool RemoveHost(WebSocket socket)
     {
         if (_hosts.TryGetByValue(socket, out var hostId))
         {
             _hostMaxClients.TryRemove(hostId, out _);
             _hostClientCount.TryRemove(hostId, out _);
+            _migratingHosts.TryRemove(hostId, out _);
+            _hostLastActivity.TryRemove(hostId, out _);
+        }
+
+        bool success;
+        lock (_hostLock)
+        {
+            // The room stays protected until its id is gone
+            success = _hosts.TryRemoveByValue(socket);
+            if (success && hostId != null)
+                _joinSecrets.TryRemove(hostId, out _);
+        }
+
+        if (success)
+        {
+            SignalingMetrics.ActiveRooms.Dec();
+            if (hostId != null)
+            {
+                PublishRoomEvent(PeerEventTypes.RoomLeft, socket, hostId, hostId, PeerRole.Host);
+            }
         }
-        var success = _hosts.TryRemoveByValue(socket);
         logger.LogDebug("Hosts size = {Count}", _hosts.Count);
         return success;
     }
@@ -130,7 +333,156 @@ public class SignalRegistry(ILogger<SignalRegistry> logger) : ISignalRegistry
         return _clientHostMap.ToArray().Where(kvp => kvp.Value == hostId).Select(kvp => kvp.Key);
     }
 
-    public void TrackSocket(WebSocket socket) => _allSockets.TryAdd(socket, 0);
+    // Taken under the capacity lock so member lists match the counts used for admission
+    public IReadOnlyList<RoomSnapshot> GetRoomSnapshots()
+    {
+        lock (_capacityLock)
+        {
+            return _hosts
+                .ToArray()
+                .Select(host => CreateRoomSnapshot(host.Key, host.Value))
+                .OrderBy(room => room.HostId)
+                .ToList();
+        }
+    }
+
+    public RoomSnapshot? GetRoomSnapshot(string hostId)
+    {
+        lock (_capacityLock)
+        {
+            return _hosts.TryGetByKey(hostId, out var hostSocket)
+                ? CreateRoomSnapshot(hostId, hostSocket)
+                : null;
+        }
+    }
+
+    private RoomSnapshot CreateRoomSnapshot(string hostId, WebSocket hostSocket)
+    {
+        var clients = GetClientsForHost(hostId)
+            .Select(socket =>
+                _clients.TryGetByValue(socket, out var clientId)
+                    ? new PeerSnapshot(clientId, GetConnectedAt(socket))
+                    : null
+            )
+            .OfType<PeerSnapshot>()
+            .OrderBy(client => client.ConnectedAt)
+            .ToList();
+
+        return new RoomSnapshot(
+            hostId,
+            GetNamespace(hostSocket),
+            GetConnectedAt(hostSocket),
+            _hostMaxClients.GetValueOrDefault(hostId),
+            clients
+        );
+    }
+
+    public IReadOnlyList<NamespaceSnapshot> GetNamespaceSnapshots()
+    {
+        var connections = _allSockets
+            .Keys.GroupBy(GetNamespace)
+            .ToDictionary(group => group.Key, group => group.Count());
+        var rooms = _hosts
+            .ToArray()
+            .GroupBy(host => GetNamespace(host.Value))
+            .ToDictionary(group => group.Key, group => group.Count());
+
+        return connections
+            .Keys.Union(rooms.Keys)
+            .Order()
+            .Select(name =>
+                new NamespaceSnapshot(
+                    name,
+                    connections.GetValueOrDefault(name),
+                    rooms.GetValueOrDefault(name)
+                )
+            )
+            .ToList();
+    }
+
+    private DateTimeOffset? GetConnectedAt(WebSocket socket) =>
+        _allSockets.TryGetValue(socket, out var tracked) ? tracked.ConnectedAt : null;
+
+    public void TrackSocket(WebSocket socket)
+    {
+        var tracked = new TrackedSocket(Guid.NewGuid().ToString("N"), _timeProvider.GetUtcNow());
+        if (_allSockets.TryAdd(socket, tracked))
+        {
+            SignalingMetrics.ActiveConnections.Inc();
+            peerEvents?.Publish(
+                new PeerEvent(PeerEventTypes.PeerConnected, tracked.ConnectedAt, tracked.Id)
+            );
+        }
+    }
+
+    // Runs on every disconnect path, so the gauge also drops for abnormal closes
+    public void UntrackSocket(WebSocket socket)
+    {
+        if (_allSockets.TryRemove(socket, out var tracked))
+        {
+            SignalingMetrics.ActiveConnections.Dec();
+            peerEvents?.Publish(
+                new PeerEvent(
+                    PeerEventTypes.PeerDisconnected,
+                    _timeProvider.GetUtcNow(),
+                    tracked.Id
+                )
+            );
+        }
+
+        _namespaces.TryRemove(socket, out _);
+        _allowedHosts.TryRemove(socket, out _);
+        _allowedActions.TryRemove(socket, out _);
+    }
+
+    public IReadOnlyCollection<WebSocket> GetTrackedSockets() => _allSockets.Keys.ToArray();
+
+    public void SetNamespace(WebSocket socket, string signalNamespace)
+    {
+        _namespaces[socket] = signalNamespace;
+    }
+
+    public string GetNamespace(WebSocket socket) =>
+        _namespaces.GetValueOrDefault(socket, SignalNamespaces.Default);
+
+    public void SetAllowedHosts(WebSocket socket, IReadOnlySet<string> hostIds)
+    {
+        _allowedHosts[socket] = hostIds;
+    }
+
+    // Sockets without a restriction (authentication disabled) may join any host
+    public bool IsHostAllowed(WebSocket socket, string hostId)
+    {
+        return !_allowedHosts.TryGetValue(socket, out var hostIds) || hostIds.Contains(hostId);
+    }
+
+    public void SetAllowedActions(WebSocket socket, IReadOnlySet<string> messageTypes)
+    {
+        _allowedActions[socket] = messageTypes;
+    }
+
+    public bool IsActionAllowed(WebSocket socket, string messageType)
+    {
+        return !_allowedActions.TryGetValue(socket, out var types) || types.Contains(messageType);
+    }
+
+    private void PublishRoomEvent(
+        string type,
+        WebSocket? socket,
+        string hostId,
+        string peerId,
+        PeerRole role
+    )
+    {
+        if (peerEvents == null)
+            return;
+
+        var connectionId =
+            socket != null && _allSockets.TryGetValue(socket, out var tracked) ? tracked.Id : null;
+        peerEvents.Publish(
+            new PeerEvent(type, _timeProvider.GetUtcNow(), connectionId, hostId, peerId, role)
+        );
+    }
 
-    public void UntrackSocket(WebSocket socket) => _allSockets.TryRemove(socket, out _);
+    private record TrackedSocket(string Id, DateTimeOffset ConnectedAt);
 }
@@ -0,0 +1,152 @@
+using Prometheus;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Prometheus metrics exported by the signaling server at <c>/metrics</c>.
+/// </summary>
+public static class SignalingMetrics
+{
+    private const string UnknownType = "unknown";
+    private const string OtherCloseCode = "other";
+    public const string BinaryType = "binary";
+
+    // Only known types become label values so arbitrary client input can't blow up cardinality
+    private static readonly HashSet<string> KnownMessageTypes =
+    [
+        SignalMessageTypes.Host,
+        SignalMessageTypes.JoinHost,
+        SignalMessageTypes.MsgToHost,
+        SignalMessageTypes.MsgToClient,
+        SignalMessageTypes.SetMetadata,
+        BinaryType,
+    ];
+
+    // Likewise for close codes: application-defined ones (4000-4999) are counted as "other"
+    private static readonly HashSet<int> KnownCloseCodes =
+    [
+        1000,
+        1001,
+        1002,
+        1003,
+        1006,
+        1007,
+        1008,
+        1009,
+        1010,
+        1011,
+    ];
+
+    public static readonly Gauge ActiveConnections = Metrics.CreateGauge(
+        "signaling_active_connections",
+        "Number of open WebSocket connections."
+    );
+
+    public static readonly Gauge MaxConnections = Metrics.CreateGauge(
+        "signaling_max_connections",
+        "Configured ceiling on open WebSocket connections (MAX_CONNECTIONS), 0 if unlimited."
+    );
+
+    public static readonly Gauge LoadShedding = Metrics.CreateGauge(
+        "signaling_load_shedding",
+        "1 while new WebSocket upgrades are being shed because of overload, otherwise 0."
+    );
+
+    public static readonly Gauge LoadSheddingHighWaterMark = Metrics.CreateGauge(
+        "signaling_load_shedding_high_water_mark",
+        "Connection count above which new upgrades are shed (LOAD_SHED_HIGH_WATER_MARK), 0 if off."
+    );
+
+    public static readonly Gauge LoadSheddingLowWaterMark = Metrics.CreateGauge(
+        "signaling_load_shedding_low_water_mark",
+        "Connection count below which shedding stops (LOAD_SHED_LOW_WATER_MARK)."
+    );
+
+    public static readonly Gauge ActiveRooms = Metrics.CreateGauge(
+        "signaling_active_rooms",
+        "Number of registered hosts, each of which forms a room with its clients."
+    );
+
+    public static readonly Gauge NamespaceConnections = Metrics.CreateGauge(
+        "signaling_namespace_connections",
+        "Number of open connections, by namespace.",
+        new GaugeConfiguration { LabelNames = ["namespace"] }
+    );
+
+    public static readonly Gauge NamespaceRooms = Metrics.CreateGauge(
+        "signaling_namespace_rooms",
+        "Number of registered hosts, by namespace.",
+        new GaugeConfiguration { LabelNames = ["namespace"] }
+    );
+
+    public static readonly Counter MessagesTotal = Metrics.CreateCounter(
+        "signaling_messages_total",
+        "Number of signaling messages received, by message type.",
+        new CounterConfiguration { LabelNames = ["type"] }
+    );
+
+    public static readonly Counter DroppedSlowConsumers = Metrics.CreateCounter(
+        "signaling_dropped_slow_consumers_total",
+        "Number of connections closed because their outbound buffer filled up."
+    );
+
+    public static readonly Counter RateLimitedMessages = Metrics.CreateCounter(
+        "signaling_rate_limited_messages_total",
+        "Number of messages rejected because their connection exceeded its message rate limit."
+    );
+
+    public static readonly Counter DisconnectsTotal = Metrics.CreateCounter(
+        "signaling_disconnects_total",
+        "Number of closed WebSocket connections, by close code (1006 for abnormal closes).",
+        new CounterConfiguration { LabelNames = ["code"] }
+    );
+
+    public static readonly Histogram MessageBytes = Metrics.CreateHistogram(
+        "signaling_message_bytes",
+        "Size of received signaling messages in bytes.",
+        new HistogramConfiguration { Buckets = Histogram.ExponentialBuckets(64, 2, 11) }
+    );
+
+    /// <summary>
+    /// Records a received message of the given type and size.
+    /// </summary>
+    public static void RecordMessage(string? type, int sizeInBytes)
+    {
+        var label = type != null && KnownMessageTypes.Contains(type) ? type : UnknownType;
+        MessagesTotal.WithLabels(label).Inc();
+        MessageBytes.Observe(sizeInBytes);
+    }
+
+    /// <summary>
+    /// Replaces the per-namespace gauges with the given breakdown. Namespaces are picked by
+    /// clients, so series of namespaces that have emptied out are removed rather than kept at 0.
+    /// </summary>
+    public static void RecordNamespaces(IReadOnlyList<NamespaceSnapshot> namespaces)
+    {
+        var live = namespaces.Select(snapshot => snapshot.Namespace).ToHashSet();
+        foreach (var labels in NamespaceConnections.GetAllLabelValues().ToList())
+        {
+            if (!live.Contains(labels[0]))
+            {
+                NamespaceConnections.RemoveLabelled(labels);
+                NamespaceRooms.RemoveLabelled(labels);
+            }
+        }
+
+        foreach (var snapshot in namespaces)
+        {
+            NamespaceConnections.WithLabels(snapshot.Namespace).Set(snapshot.Connections);
+            NamespaceRooms.WithLabels(snapshot.Namespace).Set(snapshot.Rooms);
+        }
+    }
+
+    /// <summary>
+    /// Records a closed connection under its close code.
+    /// </summary>
+    public static void RecordDisconnect(int closeCode)
+    {
+        var label = KnownCloseCodes.Contains(closeCode) ? closeCode.ToString() : OtherCloseCode;
+        DisconnectsTotal.WithLabels(label).Inc();
+    }
+}
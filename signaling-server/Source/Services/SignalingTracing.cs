@@ -0,0 +1,31 @@
+using System.Diagnostics;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// OpenTelemetry spans for connections and the messages routed over them. Spans are only
+/// recorded while a listener such as the OTLP exporter is attached; otherwise no span is created.
+/// </summary>
+public static class SignalingTracing
+{
+    public const string SourceName = "SignalingServer";
+
+    public const string MessageTypeAttribute = "msg.type";
+    public const string TargetPeerAttribute = "target.peer";
+    public const string CloseCodeAttribute = "close.code";
+
+    public static readonly ActivitySource Source = new(SourceName);
+
+    /// <summary>
+    /// Starts the root span of a connection.
+    /// </summary>
+    /// <param name="parentContext">Trace context propagated by the caller, or default for none.</param>
+    public static Activity? StartConnection(ActivityContext parentContext) =>
+        Source.StartActivity("signaling.connection", ActivityKind.Server, parentContext);
+
+    /// <summary>
+    /// Starts the span for one routed message, as a child of its connection's span.
+    /// </summary>
+    public static Activity? StartMessage(string type) =>
+        Source.StartActivity("signaling.message")?.SetTag(MessageTypeAttribute, type);
+}
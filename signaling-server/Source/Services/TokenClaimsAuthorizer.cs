@@ -0,0 +1,18 @@
+using System.Net.WebSockets;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Enforces the <see cref="JwtAuthenticator.RoomsClaim"/> and
+/// <see cref="JwtAuthenticator.ActionsClaim"/> claims of the token the connection presented.
+/// Connections whose token didn't restrict rooms or actions are allowed everything.
+/// </summary>
+public class TokenClaimsAuthorizer(ISignalRegistry signalRegistry) : IRoomAuthorizer
+{
+    public bool CanJoin(WebSocket socket, string hostId) =>
+        signalRegistry.IsHostAllowed(socket, hostId);
+
+    public bool CanSend(WebSocket socket, SignalMessage message) =>
+        message.Type != null && signalRegistry.IsActionAllowed(socket, message.Type);
+}
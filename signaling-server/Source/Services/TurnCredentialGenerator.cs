@@ -0,0 +1,72 @@
+using System.Security.Cryptography;
+using System.Text;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Issues short-lived TURN credentials following the coturn REST API convention (use-auth-secret):
+/// the username is "&lt;expiry-unix-timestamp&gt;:&lt;peerId&gt;" and the password is the base64
+/// HMAC-SHA1 of that username keyed by the secret shared with the TURN server.
+/// </summary>
+public class TurnCredentialGenerator(
+    string? secret,
+    IReadOnlyList<string> urls,
+    TimeSpan ttl,
+    TimeProvider timeProvider
+)
+{
+    public bool IsConfigured => !string.IsNullOrEmpty(secret);
+
+    /// <summary>
+    /// Generates credentials for the given peer that expire after the configured TTL.
+    /// </summary>
+    /// <exception cref="InvalidOperationException">Thrown if no shared secret is configured.</exception>
+    public TurnCredentials Generate(string peerId)
+    {
+        if (string.IsNullOrEmpty(secret))
+            throw new InvalidOperationException("TURN shared secret is not configured");
+
+        var expiry = timeProvider.GetUtcNow().Add(ttl).ToUnixTimeSeconds();
+        var username = $"{expiry}:{peerId}";
+
+        return new TurnCredentials
+        {
+            Username = username,
+            Password = ComputePassword(secret, username),
+            Ttl = (int)ttl.TotalSeconds,
+            Urls = urls,
+        };
+    }
+
+    /// <summary>
+    /// Creates a generator configured from TURN_SECRET, TURN_URLS (comma-separated) and
+    /// TURN_CREDENTIAL_TTL_SECONDS (12 hours by default).
+    /// </summary>
+    public static TurnCredentialGenerator FromEnvironment()
+    {
+        var urls = (Environment.GetEnvironmentVariable("TURN_URLS") ?? "").Split(
+            ',',
+            StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries
+        );
+        var ttlSeconds = int.Parse(
+            Environment.GetEnvironmentVariable("TURN_CREDENTIAL_TTL_SECONDS") ?? "43200"
+        );
+
+        return new TurnCredentialGenerator(
+            Environment.GetEnvironmentVariable("TURN_SECRET"),
+            urls,
+            TimeSpan.FromSeconds(ttlSeconds),
+            TimeProvider.System
+        );
+    }
+
+    public static string ComputePassword(string secret, string username)
+    {
+        var hash = HMACSHA1.HashData(
+            Encoding.UTF8.GetBytes(secret),
+            Encoding.UTF8.GetBytes(username)
+        );
+        return Convert.ToBase64String(hash);
+    }
+}
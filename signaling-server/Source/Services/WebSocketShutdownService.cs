@@ -0,0 +1,65 @@
+using System.Net.WebSockets;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Sends a going-away close frame to every open WebSocket when the host is shutting down,
+/// so clients can reconnect to another instance instead of being cut off mid-handshake.
+/// The frames go out while the host is stopping, before any hosted service stops: by the time
+/// the web server's own stop runs it has already waited out the shutdown timeout and aborted
+/// the upgraded connections.
+/// </summary>
+public class WebSocketShutdownService(
+    ISignalRegistry signalRegistry,
+    ReadinessState readinessState,
+    IHostApplicationLifetime lifetime,
+    ILogger<WebSocketShutdownService> logger
+) : IHostedLifecycleService
+{
+    public Task StartingAsync(CancellationToken cancellationToken) => Task.CompletedTask;
+
+    public Task StartAsync(CancellationToken cancellationToken)
+    {
+        // Fails /readyz as soon as shutdown is requested, while requests are still served, so
+        // load balancers see the instance draining
+        lifetime.ApplicationStopping.Register(readinessState.MarkShuttingDown);
+        return Task.CompletedTask;
+    }
+
+    public Task StartedAsync(CancellationToken cancellationToken) => Task.CompletedTask;
+
+    public async Task StoppingAsync(CancellationToken cancellationToken)
+    {
+        var sockets = signalRegistry.GetTrackedSockets();
+        logger.LogInformation(
+            "Shutting down, closing {Count} WebSocket connections",
+            sockets.Count
+        );
+
+        var tasks = sockets
+            .Where(socket => socket.State == WebSocketState.Open)
+            .Select(async socket =>
+            {
+                try
+                {
+                    // Only the output side is closed: the connection's receive loop is still
+                    // running and completes the handshake when the client answers.
+                    await socket.CloseOutputAsync(
+                        WebSocketCloseStatus.EndpointUnavailable,
+                        "Server shutting down",
+                        cancellationToken
+                    );
+                }
+                catch (Exception ex) when (ex is WebSocketException or OperationCanceledException)
+                {
+                    logger.LogWarning(ex, "Failed to send going-away close frame");
+                }
+            });
+
+        await Task.WhenAll(tasks);
+    }
+
+    public Task StopAsync(CancellationToken cancellationToken) => Task.CompletedTask;
+
+    public Task StoppedAsync(CancellationToken cancellationToken) => Task.CompletedTask;
+}
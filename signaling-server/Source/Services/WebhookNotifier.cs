@@ -0,0 +1,130 @@
+using System.Security.Cryptography;
+using System.Text;
+using System.Threading.Channels;
+using SignalingServer.Configuration;
+using SignalingServer.Models;
+
+namespace SignalingServer.Services;
+
+/// <summary>
+/// Delivers lifecycle events to the configured webhook from a bounded in-memory queue, so a
+/// slow or failing endpoint never holds up signaling. Failed deliveries are retried with
+/// exponential backoff; events that don't fit in the queue are dropped.
+/// </summary>
+public class WebhookNotifier(
+    WebhookOptions options,
+    HttpClient httpClient,
+    ILogger<WebhookNotifier> logger
+) : BackgroundService, IPeerEventPublisher
+{
+    public const string SignatureHeader = "X-Signature-256";
+
+    private readonly Channel<PeerEvent> _queue = Channel.CreateBounded<PeerEvent>(
+        new BoundedChannelOptions(options.QueueSize)
+        {
+            SingleReader = true,
+            FullMode = BoundedChannelFullMode.Wait,
+        }
+    );
+
+    public void Publish(PeerEvent peerEvent)
+    {
+        if (!options.IsEnabled)
+            return;
+
+        if (!_queue.Writer.TryWrite(peerEvent))
+        {
+            logger.LogWarning("Webhook queue is full, dropping {EventType} event", peerEvent.Type);
+        }
+    }
+
+    protected override async Task ExecuteAsync(CancellationToken stoppingToken)
+    {
+        if (!options.IsEnabled)
+            return;
+
+        await foreach (var peerEvent in _queue.Reader.ReadAllAsync(stoppingToken))
+        {
+            await DeliverAsync(peerEvent, stoppingToken);
+        }
+    }
+
+    /// <summary>
+    /// POSTs one event, retrying until it is accepted or the attempts run out.
+    /// </summary>
+    /// <returns>Whether the endpoint accepted the event.</returns>
+    public async Task<bool> DeliverAsync(PeerEvent peerEvent, CancellationToken cancellationToken)
+    {
+        var body = peerEvent.ToJson();
+        var signature = options.Secret != null ? Sign(options.Secret, body) : null;
+
+        for (var attempt = 1; ; attempt++)
+        {
+            try
+            {
+                using var request = new HttpRequestMessage(HttpMethod.Post, options.Url)
+                {
+                    Content = new StringContent(body, Encoding.UTF8, "application/json"),
+                };
+                if (signature != null)
+                {
+                    request.Headers.Add(SignatureHeader, signature);
+                }
+
+                using var response = await httpClient.SendAsync(request, cancellationToken);
+                if (response.IsSuccessStatusCode)
+                    return true;
+
+                logger.LogWarning(
+                    "Webhook answered {StatusCode} to {EventType} (attempt {Attempt})",
+                    (int)response.StatusCode,
+                    peerEvent.Type,
+                    attempt
+                );
+            }
+            catch (HttpRequestException ex)
+            {
+                logger.LogWarning(
+                    ex,
+                    "Webhook delivery of {EventType} failed (attempt {Attempt})",
+                    peerEvent.Type,
+                    attempt
+                );
+            }
+            catch (TaskCanceledException ex) when (!cancellationToken.IsCancellationRequested)
+            {
+                logger.LogWarning(
+                    ex,
+                    "Webhook delivery of {EventType} timed out (attempt {Attempt})",
+                    peerEvent.Type,
+                    attempt
+                );
+            }
+
+            if (attempt >= options.MaxAttempts)
+            {
+                logger.LogError(
+                    "Giving up on {EventType} event after {Attempts} attempts",
+                    peerEvent.Type,
+                    attempt
+                );
+                return false;
+            }
+
+            await Task.Delay(options.RetryDelay * Math.Pow(2, attempt - 1), cancellationToken);
+        }
+    }
+
+    /// <summary>
+    /// Computes the <see cref="SignatureHeader"/> value: "sha256=" followed by the hex-encoded
+    /// HMAC-SHA256 of the request body, keyed with the shared secret.
+    /// </summary>
+    public static string Sign(string secret, string body)
+    {
+        var hash = HMACSHA256.HashData(
+            Encoding.UTF8.GetBytes(secret),
+            Encoding.UTF8.GetBytes(body)
+        );
+        return "sha256=" + Convert.ToHexStringLower(hash);
+    }
+}
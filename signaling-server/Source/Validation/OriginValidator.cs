@@ -4,8 +4,18 @@ using System.Linq;
 using Microsoft.AspNetCore.Http;
 using Microsoft.Extensions.Hosting;
 
-public class OriginValidator(IWebHostEnvironment environment)
+public class OriginValidator(IWebHostEnvironment environment, string? allowedOrigins = null)
 {
+    private const string Wildcard = "*";
+
+    /// <summary>
+    /// Origins configured through ALLOWED_ORIGINS (comma-separated), or null to use the defaults.
+    /// Entries may be an exact origin, "*" for any origin, or "*.example.com" for subdomains.
+    /// </summary>
+    private readonly string[]? _allowedOrigins = ParseAllowedOrigins(
+        allowedOrigins ?? Environment.GetEnvironmentVariable("ALLOWED_ORIGINS")
+    );
+
     /// <summary>
     /// Checks if the given origin string is allowed.
     /// Used for both WebSocket origin validation and CORS policy.
@@ -15,6 +25,9 @@ public class OriginValidator(IWebHostEnvironment environment)
         if (string.IsNullOrEmpty(origin))
             return false;
 
+        if (_allowedOrigins != null)
+            return _allowedOrigins.Any(allowed => Matches(allowed, origin));
+
         switch (origin)
         {
             case "https://squarespheres.com":
@@ -53,4 +66,26 @@ public class OriginValidator(IWebHostEnvironment environment)
         var origin = originValues.FirstOrDefault();
         return IsOriginAllowed(origin);
     }
+
+    private static bool Matches(string allowed, string origin)
+    {
+        if (allowed == Wildcard)
+            return true;
+
+        if (allowed.StartsWith("*."))
+            return origin.EndsWith(allowed[1..], StringComparison.OrdinalIgnoreCase);
+
+        return string.Equals(allowed, origin, StringComparison.OrdinalIgnoreCase);
+    }
+
+    private static string[]? ParseAllowedOrigins(string? value)
+    {
+        if (string.IsNullOrWhiteSpace(value))
+            return null;
+
+        return value.Split(
+            ',',
+            StringSplitOptions.RemoveEmptyEntries | StringSplitOptions.TrimEntries
+        );
+    }
 }
@@ -0,0 +1,41 @@
+using System.Text.RegularExpressions;
+
+namespace SignalingServer.Validation;
+
+/// <summary>
+/// Checks host ids sent by clients against ROOM_ID_PATTERN before they are used, so arbitrary
+/// strings never reach the registry or the logs.
+/// </summary>
+public class RoomIdValidator
+{
+    public const string DefaultPattern = "^[A-Za-z0-9_-]{1,64}$";
+
+    // Guards against a configured pattern that backtracks badly on hostile input
+    private static readonly TimeSpan MatchTimeout = TimeSpan.FromMilliseconds(100);
+
+    private readonly Regex _pattern;
+
+    /// <param name="pattern">The pattern ids must match, or null to read ROOM_ID_PATTERN.</param>
+    /// <exception cref="ArgumentException">The pattern is not a valid regular expression.</exception>
+    public RoomIdValidator(string? pattern = null)
+    {
+        pattern ??= Environment.GetEnvironmentVariable("ROOM_ID_PATTERN");
+        _pattern = new Regex(
+            string.IsNullOrWhiteSpace(pattern) ? DefaultPattern : pattern,
+            RegexOptions.Compiled | RegexOptions.CultureInvariant,
+            MatchTimeout
+        );
+    }
+
+    public bool IsValid(string roomId)
+    {
+        try
+        {
+            return _pattern.IsMatch(roomId);
+        }
+        catch (RegexMatchTimeoutException)
+        {
+            return false;
+        }
+    }
+}
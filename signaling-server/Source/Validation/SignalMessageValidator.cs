@@ -43,6 +43,16 @@ public class SignalMessageValidator : AbstractValidator<SignalMessage>
             }
         );
 
+        When(
+            m => m.Type == SignalMessageTypes.SetMetadata,
+            () =>
+            {
+                RuleFor(m => m.Metadata)
+                    .NotNull()
+                    .WithMessage("Metadata is required when Type is 'set-metadata'.");
+            }
+        );
+
         // You can optionally enforce that 'host' type doesn't require anything
         When(
             m => m.Type == SignalMessageTypes.Host,
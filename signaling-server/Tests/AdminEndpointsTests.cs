@@ -0,0 +1,412 @@
+using System.Net.WebSockets;
+using System.Text.Json;
+using Microsoft.AspNetCore.Http;
+using Microsoft.AspNetCore.Http.HttpResults;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+using Moq;
+using SignalingServer.Endpoints;
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class AdminEndpointsTests
+{
+    /// <summary>
+    /// A connected peer whose receive loop ends once the server sends it a close frame.
+    /// </summary>
+    private class ClosableWebSocket : TestWebSocket
+    {
+        private readonly TaskCompletionSource _closed = new(
+            TaskCreationOptions.RunContinuationsAsynchronously
+        );
+
+        public int MessagesBeforeClose { get; private set; }
+
+        public override Task CloseOutputAsync(
+            WebSocketCloseStatus closeStatus,
+            string? statusDescription,
+            CancellationToken cancellationToken
+        )
+        {
+            MessagesBeforeClose = SentMessages.Count;
+            _closed.TrySetResult();
+            return base.CloseOutputAsync(closeStatus, statusDescription, cancellationToken);
+        }
+
+        public override async Task<WebSocketReceiveResult> ReceiveAsync(
+            ArraySegment<byte> buffer,
+            CancellationToken cancellationToken
+        )
+        {
+            await _closed.Task;
+            return new WebSocketReceiveResult(0, WebSocketMessageType.Close, true);
+        }
+    }
+
+    /// <summary>
+    /// A peer whose connection was disposed while it was still registered.
+    /// </summary>
+    private class DisposedWebSocket : TestWebSocket
+    {
+        public override Task SendAsync(
+            ArraySegment<byte> buffer,
+            WebSocketMessageType messageType,
+            bool endOfMessage,
+            CancellationToken cancellationToken
+        ) => throw new ObjectDisposedException(nameof(WebSocket));
+    }
+
+    private SignalRegistry _registry;
+    private TestWebSocket _hostSocket;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _registry = new SignalRegistry(new Mock<ILogger<SignalRegistry>>().Object);
+
+        _hostSocket = new TestWebSocket();
+        _registry.TrackSocket(_hostSocket);
+        _registry.RegisterHost("HOST01", _hostSocket, maxClients: 4);
+
+        foreach (var clientId in new[] { "CLI001", "CLI002" })
+        {
+            var clientSocket = new TestWebSocket();
+            _registry.TrackSocket(clientSocket);
+            _registry.RegisterClient(clientId, clientSocket, "HOST01");
+        }
+
+        var otherAppHost = new TestWebSocket();
+        _registry.TrackSocket(otherAppHost);
+        _registry.SetNamespace(otherAppHost, "app-b");
+        _registry.RegisterHost("HOST02", otherAppHost);
+    }
+
+    [TearDown]
+    public void TearDown()
+    {
+        foreach (var socket in _registry.GetTrackedSockets())
+        {
+            _registry.UntrackSocket(socket);
+        }
+    }
+
+    private static HttpContext CreateContext(string? authorization)
+    {
+        var context = new DefaultHttpContext();
+        if (authorization != null)
+            context.Request.Headers.Authorization = authorization;
+        return context;
+    }
+
+    [Test]
+    public void GetRooms_ListsEveryRoomWithMemberCount()
+    {
+        var result = AdminEndpoints.GetRooms(_registry);
+
+        var rooms = ((JsonHttpResult<IReadOnlyList<RoomSnapshot>>)result).Value!;
+        Assert.Multiple(() =>
+        {
+            Assert.That(rooms.Select(r => r.HostId), Is.EqualTo(new[] { "HOST01", "HOST02" }));
+            Assert.That(rooms[0].MemberCount, Is.EqualTo(2));
+            Assert.That(rooms[1].MemberCount, Is.EqualTo(0));
+        });
+    }
+
+    [Test]
+    public void GetRooms_WithNamespace_ListsOnlyThatNamespacesRooms()
+    {
+        var result = AdminEndpoints.GetRooms(_registry, "app-b");
+
+        var rooms = ((JsonHttpResult<IReadOnlyList<RoomSnapshot>>)result).Value!;
+        Assert.That(rooms.Select(r => r.HostId), Is.EqualTo(new[] { "HOST02" }));
+    }
+
+    [Test]
+    public void GetNamespaces_ReportsConnectionsAndRoomsPerNamespace()
+    {
+        var result = AdminEndpoints.GetNamespaces(_registry);
+
+        var namespaces = ((JsonHttpResult<IReadOnlyList<NamespaceSnapshot>>)result).Value!;
+        Assert.That(
+            namespaces,
+            Is.EqualTo(
+                new[]
+                {
+                    new NamespaceSnapshot("app-b", Connections: 1, Rooms: 1),
+                    new NamespaceSnapshot(SignalNamespaces.Default, Connections: 3, Rooms: 1),
+                }
+            )
+        );
+    }
+
+    [Test]
+    public void GetRoom_ReturnsPeersWithConnectedSince()
+    {
+        var result = AdminEndpoints.GetRoom("HOST01", _registry);
+
+        var room = ((JsonHttpResult<RoomSnapshot>)result).Value!;
+        Assert.Multiple(() =>
+        {
+            Assert.That(room.MaxClients, Is.EqualTo(4));
+            Assert.That(room.ConnectedAt, Is.Not.Null);
+            Assert.That(
+                room.Clients.Select(c => c.PeerId),
+                Is.EquivalentTo(new[] { "CLI001", "CLI002" })
+            );
+            Assert.That(room.Clients.All(c => c.ConnectedAt != null), Is.True);
+        });
+    }
+
+    [Test]
+    public void GetRoom_UnknownRoom_ReturnsNotFound()
+    {
+        var result = AdminEndpoints.GetRoom("NOPE00", _registry);
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status404NotFound)
+        );
+    }
+
+    [TestCase(null)]
+    [TestCase("Bearer wrong-token")]
+    [TestCase("admin-token")]
+    public void Authorize_WithoutValidToken_ReturnsUnauthorized(string? authorization)
+    {
+        var result = AdminEndpoints.Authorize(CreateContext(authorization), "admin-token");
+
+        Assert.That(
+            (result as IStatusCodeHttpResult)?.StatusCode,
+            Is.EqualTo(StatusCodes.Status401Unauthorized)
+        );
+    }
+
+    [Test]
+    public void Authorize_WithoutConfiguredToken_ReturnsUnauthorized()
+    {
+        var result = AdminEndpoints.Authorize(CreateContext("Bearer anything"), null);
+
+        Assert.That(result, Is.Not.Null);
+    }
+
+    [Test]
+    public void Authorize_WithValidToken_ReturnsNull()
+    {
+        var result = AdminEndpoints.Authorize(CreateContext("Bearer admin-token"), "admin-token");
+
+        Assert.That(result, Is.Null);
+    }
+
+    [Test]
+    public async Task KickPeer_ClosesConnectionAndRemovesItFromRoom()
+    {
+        var messageHandler = new MessageHandler(
+            _registry,
+            new SessionTokenService(
+                "test-secret"u8.ToArray(),
+                TimeSpan.FromMinutes(5),
+                TimeProvider.System
+            ),
+            new Mock<ILogger<MessageHandler>>().Object
+        );
+        var connectionHandler = new ConnectionHandler(
+            messageHandler,
+            _registry,
+            new Mock<ILogger<ConnectionHandler>>().Object
+        );
+
+        var peerSocket = new ClosableWebSocket();
+        var connection = connectionHandler.HandleConnection(peerSocket, CancellationToken.None);
+        _registry.RegisterClient("KICKME", peerSocket, "HOST01");
+
+        var result = await AdminEndpoints.KickPeer(
+            "HOST01",
+            "KICKME",
+            new KickRequest { Reason = "spam" },
+            _registry,
+            NullLoggerFactory.Instance
+        );
+        await connection;
+
+        var notification = JsonSerializer.Deserialize<SignalMessage>(
+            _hostSocket.SentMessages[^1]
+        );
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                ((IStatusCodeHttpResult)result).StatusCode,
+                Is.EqualTo(StatusCodes.Status200OK)
+            );
+            Assert.That(
+                peerSocket.SentCloseStatus,
+                Is.EqualTo(WebSocketCloseStatus.PolicyViolation)
+            );
+            Assert.That(peerSocket.SentCloseDescription, Is.EqualTo("kicked: spam"));
+            Assert.That(_registry.TryGetClientSocket("KICKME", out _), Is.False);
+            Assert.That(_registry.GetRoomSnapshot("HOST01")!.MemberCount, Is.EqualTo(2));
+            Assert.That(notification?.Type, Is.EqualTo(SignalMessageTypes.ClientDisconnected));
+            Assert.That(notification?.ClientId, Is.EqualTo("KICKME"));
+        });
+    }
+
+    [Test]
+    public async Task KickPeer_LongReason_IsTruncatedOnACharacterBoundary()
+    {
+        var peerSocket = new TestWebSocket();
+        _registry.RegisterClient("KICKME", peerSocket, "HOST01");
+
+        // "kicked: " leaves 115 bytes, which ends partway through the 29th four-byte emoji
+        await AdminEndpoints.KickPeer(
+            "HOST01",
+            "KICKME",
+            new KickRequest { Reason = string.Concat(Enumerable.Repeat("🎲", 40)) },
+            _registry,
+            NullLoggerFactory.Instance
+        );
+
+        Assert.That(
+            peerSocket.SentCloseDescription,
+            Is.EqualTo("kicked: " + string.Concat(Enumerable.Repeat("🎲", 28)))
+        );
+    }
+
+    [Test]
+    public async Task KickPeer_UnknownPeer_ReturnsNotFound()
+    {
+        var result = await AdminEndpoints.KickPeer(
+            "HOST01",
+            "NOPE00",
+            null,
+            _registry,
+            NullLoggerFactory.Instance
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status404NotFound)
+        );
+    }
+
+    [Test]
+    public async Task KickPeer_PeerOfAnotherRoom_ReturnsNotFound()
+    {
+        var result = await AdminEndpoints.KickPeer(
+            "HOST02",
+            "CLI001",
+            null,
+            _registry,
+            NullLoggerFactory.Instance
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status404NotFound)
+        );
+    }
+
+    [Test]
+    public async Task MigrateRoomAsync_SendsTargetUrlToEveryMemberBeforeClosing()
+    {
+        var hostSocket = new ClosableWebSocket();
+        var clientSockets = new[] { new ClosableWebSocket(), new ClosableWebSocket() };
+        _registry.RegisterHost("MIGR01", hostSocket);
+        _registry.RegisterClient("MCLI01", clientSockets[0], "MIGR01");
+        _registry.RegisterClient("MCLI02", clientSockets[1], "MIGR01");
+
+        Assert.That(_registry.MarkMigrating("MIGR01"), Is.True);
+        await AdminEndpoints.MigrateRoomAsync(
+            "MIGR01",
+            "wss://signal-2.example.com/ws",
+            TimeSpan.Zero,
+            _registry,
+            NullLogger.Instance
+        );
+
+        foreach (var member in clientSockets.Append(hostSocket))
+        {
+            var migrate = JsonSerializer.Deserialize<SignalMessage>(member.SentMessages[0]);
+            Assert.Multiple(() =>
+            {
+                Assert.That(migrate?.Type, Is.EqualTo(SignalMessageTypes.Migrate));
+                Assert.That(migrate?.Url, Is.EqualTo("wss://signal-2.example.com/ws"));
+                Assert.That(member.MessagesBeforeClose, Is.EqualTo(1));
+                Assert.That(
+                    member.SentCloseStatus,
+                    Is.EqualTo(WebSocketCloseStatus.EndpointUnavailable)
+                );
+                Assert.That(member.SentCloseDescription, Is.EqualTo("migrating"));
+            });
+        }
+    }
+
+    [Test]
+    public async Task MigrateRoomAsync_MemberSendFails_StillClosesTheRoom()
+    {
+        var hostSocket = new ClosableWebSocket();
+        var clientSocket = new DisposedWebSocket();
+        _registry.RegisterHost("MIGR01", hostSocket);
+        _registry.RegisterClient("MCLI01", clientSocket, "MIGR01");
+
+        Assert.That(_registry.MarkMigrating("MIGR01"), Is.True);
+        await AdminEndpoints.MigrateRoomAsync(
+            "MIGR01",
+            "wss://signal-2.example.com/ws",
+            TimeSpan.Zero,
+            _registry,
+            NullLogger.Instance
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(hostSocket.MessagesBeforeClose, Is.EqualTo(1));
+            Assert.That(
+                clientSocket.SentCloseStatus,
+                Is.EqualTo(WebSocketCloseStatus.EndpointUnavailable)
+            );
+            Assert.That(
+                hostSocket.SentCloseStatus,
+                Is.EqualTo(WebSocketCloseStatus.EndpointUnavailable)
+            );
+        });
+    }
+
+    [Test]
+    public void MigrateRoom_RejectsNonWebSocketUrl()
+    {
+        var result = AdminEndpoints.MigrateRoom(
+            "HOST01",
+            new MigrateRequest { Url = "ftp://example.com" },
+            _registry,
+            NullLoggerFactory.Instance
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                ((IStatusCodeHttpResult)result).StatusCode,
+                Is.EqualTo(StatusCodes.Status400BadRequest)
+            );
+            Assert.That(_registry.IsMigrating("HOST01"), Is.False);
+        });
+    }
+
+    [Test]
+    public void MigrateRoom_UnknownRoom_ReturnsNotFound()
+    {
+        var result = AdminEndpoints.MigrateRoom(
+            "NOPE00",
+            new MigrateRequest { Url = "wss://signal-2.example.com/ws" },
+            _registry,
+            NullLoggerFactory.Instance
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status404NotFound)
+        );
+    }
+}
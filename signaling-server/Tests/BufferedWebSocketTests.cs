@@ -0,0 +1,129 @@
+using System.Net.WebSockets;
+using SignalingServer.Extensions;
+using SignalingServer.Helpers;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class BufferedWebSocketTests
+{
+    /// <summary>
+    /// A peer that never reads: every send blocks until the connection is aborted.
+    /// </summary>
+    private class StalledWebSocket : TestWebSocket
+    {
+        private readonly TaskCompletionSource _aborted = new(
+            TaskCreationOptions.RunContinuationsAsynchronously
+        );
+
+        public Task Aborted => _aborted.Task;
+
+        public override async Task SendAsync(
+            ArraySegment<byte> buffer,
+            WebSocketMessageType messageType,
+            bool endOfMessage,
+            CancellationToken cancellationToken
+        )
+        {
+            await _aborted.Task;
+            throw new WebSocketException(WebSocketError.ConnectionClosedPrematurely);
+        }
+
+        public override async Task CloseOutputAsync(
+            WebSocketCloseStatus closeStatus,
+            string? statusDescription,
+            CancellationToken cancellationToken
+        )
+        {
+            // The close frame is stuck behind the pending send, like on a real socket
+            await Task.Delay(Timeout.Infinite, cancellationToken);
+        }
+
+        public override void Abort() => _aborted.TrySetResult();
+    }
+
+    private static async Task WaitUntil(Func<bool> condition)
+    {
+        for (var i = 0; i < 100 && !condition(); i++)
+        {
+            await Task.Delay(20);
+        }
+    }
+
+    [Test]
+    public async Task SendAsync_QueuesMessagesAndWritesThemInOrder()
+    {
+        var inner = new TestWebSocket();
+        var socket = new BufferedWebSocket(inner, capacity: 8);
+
+        await socket.SendRawAsync("first");
+        await socket.SendRawAsync("second");
+        await WaitUntil(() => inner.SentMessages.Count == 2);
+
+        Assert.That(inner.SentMessages, Is.EqualTo(new[] { "first", "second" }));
+    }
+
+    [Test]
+    public async Task SendAsync_WhenBufferIsFull_DropsSlowConsumerWithoutBlockingOthers()
+    {
+        var stalledInner = new StalledWebSocket();
+        var stalled = new BufferedWebSocket(stalledInner, capacity: 2);
+        var healthyInner = new TestWebSocket();
+        var healthy = new BufferedWebSocket(healthyInner, capacity: 2);
+        var droppedBefore = SignalingMetrics.DroppedSlowConsumers.Value;
+
+        // One message is stuck in the writer, two fill the buffer, the rest overflow
+        for (var i = 0; i < 5; i++)
+        {
+            var send = Task.WhenAll(
+                stalled.SendRawAsync($"msg-{i}"),
+                healthy.SendRawAsync($"msg-{i}")
+            );
+            Assert.That(send.IsCompleted, Is.True, "Sending must never wait on a slow peer");
+            await send;
+            await WaitUntil(() => healthyInner.SentMessages.Count == i + 1);
+        }
+
+        var aborted = await Task.WhenAny(stalledInner.Aborted, Task.Delay(5000));
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(aborted, Is.SameAs(stalledInner.Aborted));
+            Assert.That(stalled.IsDropped, Is.True);
+            Assert.That(healthy.IsDropped, Is.False);
+            Assert.That(healthyInner.SentMessages, Has.Count.EqualTo(5));
+            Assert.That(
+                SignalingMetrics.DroppedSlowConsumers.Value,
+                Is.EqualTo(droppedBefore + 1)
+            );
+        });
+    }
+
+    [Test]
+    public async Task SendAsync_AfterClose_IsDiscardedWithoutDroppingTheSocket()
+    {
+        var inner = new TestWebSocket();
+        var socket = new BufferedWebSocket(inner, capacity: 2);
+        var droppedBefore = SignalingMetrics.DroppedSlowConsumers.Value;
+
+        await socket.CloseOutputAsync(
+            WebSocketCloseStatus.NormalClosure,
+            null,
+            CancellationToken.None
+        );
+        await socket.SendRawAsync("too-late");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(socket.IsDropped, Is.False);
+            Assert.That(inner.SentMessages, Is.Empty);
+            Assert.That(inner.SentCloseStatus, Is.EqualTo(WebSocketCloseStatus.NormalClosure));
+            Assert.That(
+                SignalingMetrics.DroppedSlowConsumers.Value,
+                Is.EqualTo(droppedBefore)
+            );
+        });
+    }
+}
@@ -0,0 +1,40 @@
+using SignalingServer.Configuration;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class BuildInfoTests
+{
+    [Test]
+    public void Resolve_PrefersStampedValues()
+    {
+        var info = BuildInfo.Resolve("abc1234", "2025-07-05T17:05:06Z", "1.0.0+fallback");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(info.Commit, Is.EqualTo("abc1234"));
+            Assert.That(info.BuildTime, Is.EqualTo("2025-07-05T17:05:06Z"));
+            Assert.That(info.Runtime, Does.StartWith(".NET"));
+        });
+    }
+
+    [Test]
+    public void Resolve_WithoutStampedValues_FallsBackToInformationalVersion()
+    {
+        var info = BuildInfo.Resolve("", null, "1.0.0+9f8e7d6c");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(info.Commit, Is.EqualTo("9f8e7d6c"));
+            Assert.That(info.BuildTime, Is.EqualTo("unknown"));
+        });
+    }
+
+    [Test]
+    public void Resolve_WithoutAnySource_ReportsUnknownCommit()
+    {
+        var info = BuildInfo.Resolve(null, null, "1.0.0");
+
+        Assert.That(info.Commit, Is.EqualTo("unknown"));
+    }
+}
@@ -1,14 +1,57 @@
+using System.Diagnostics;
 using System.Net.WebSockets;
+using System.Text.Json;
 using Microsoft.Extensions.Logging;
 using Moq;
+using SignalingServer.Configuration;
 using SignalingServer.Models;
 using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
 
 namespace SignalingServer.Tests;
 
 [TestFixture]
 public class ConnectionHandlerTests
 {
+    /// <summary>
+    /// A peer that sends the given number of messages back to back, then a close frame.
+    /// </summary>
+    private class FloodingWebSocket(int messageCount) : TestWebSocket
+    {
+        private int _remaining = messageCount;
+        private bool _closed;
+
+        public override WebSocketState State =>
+            _closed ? WebSocketState.Closed : WebSocketState.Open;
+
+        public override Task<WebSocketReceiveResult> ReceiveAsync(
+            ArraySegment<byte> buffer,
+            CancellationToken cancellationToken
+        )
+        {
+            if (_remaining-- <= 0)
+            {
+                _closed = true;
+                return Task.FromResult(
+                    new WebSocketReceiveResult(0, WebSocketMessageType.Close, true)
+                );
+            }
+
+            "{}"u8.CopyTo(buffer.AsSpan());
+            return Task.FromResult(new WebSocketReceiveResult(2, WebSocketMessageType.Text, true));
+        }
+
+        public override Task CloseAsync(
+            WebSocketCloseStatus closeStatus,
+            string? statusDescription,
+            CancellationToken cancellationToken
+        )
+        {
+            _closed = true;
+            return base.CloseAsync(closeStatus, statusDescription, cancellationToken);
+        }
+    }
+
     private Mock<IMessageHandler> _messageHandlerMock;
     private Mock<ISignalRegistry> _signalRegistryMock;
     private Mock<ILogger<ConnectionHandler>> _loggerMock;
@@ -112,6 +155,107 @@ public class ConnectionHandlerTests
         Assert.That(actualType, Is.EqualTo(DisconnectionType.Client));
     }
 
+    [Test]
+    public async Task HandleConnection_AbortedClientSocket_IsRemovedFromHost()
+    {
+        // A keep-alive timeout aborts the socket, which surfaces as a failed receive
+        var socketMock = new Mock<WebSocket>();
+
+        socketMock.Setup(s => s.State).Returns(WebSocketState.Open);
+
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ThrowsAsync(new WebSocketException(WebSocketError.ConnectionClosedPrematurely));
+
+        _signalRegistryMock
+            .Setup(r => r.TryGetHostId(socketMock.Object, out It.Ref<string>.IsAny!))
+            .Returns(false);
+
+        _signalRegistryMock
+            .Setup(r => r.TryGetClientHost(socketMock.Object, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket s, out string hostId) =>
+                {
+                    hostId = "host-3";
+                    return true;
+                }
+            );
+
+        DisconnectionType? actualType = null;
+        _handler.SocketDisconnected += (_, type) => actualType = type;
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None);
+
+        _messageHandlerMock.Verify(
+            m => m.HandleDisconnect(socketMock.Object, DisconnectionType.Client),
+            Times.Once
+        );
+        _signalRegistryMock.Verify(r => r.RemoveClient(socketMock.Object), Times.Once);
+        _signalRegistryMock.Verify(r => r.UntrackSocket(socketMock.Object), Times.Once);
+        Assert.That(actualType, Is.EqualTo(DisconnectionType.Client));
+    }
+
+    [Test]
+    public async Task HandleConnection_BinaryMessage_IsRoutedToBinaryHandler()
+    {
+        var socketMock = new Mock<WebSocket>();
+
+        socketMock
+            .SetupSequence(s => s.State)
+            .Returns(WebSocketState.Open)
+            .Returns(WebSocketState.Closed);
+
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ReturnsAsync(new WebSocketReceiveResult(8, WebSocketMessageType.Binary, true));
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None);
+
+        _messageHandlerMock.Verify(
+            m => m.HandleBinaryMessage(socketMock.Object, It.Is<byte[]>(b => b.Length == 8)),
+            Times.Once
+        );
+        _messageHandlerMock.Verify(
+            m => m.HandleMessage(It.IsAny<WebSocket>(), It.IsAny<string>()),
+            Times.Never
+        );
+    }
+
+    [Test]
+    public async Task HandleConnection_OversizedMessage_ClosesWithMessageTooBig()
+    {
+        var socketMock = new Mock<WebSocket>();
+
+        socketMock.Setup(s => s.State).Returns(WebSocketState.Open);
+
+        // A message that never ends keeps growing until it crosses the size limit
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ReturnsAsync(new WebSocketReceiveResult(4096, WebSocketMessageType.Text, false));
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None);
+
+        _messageHandlerMock.Verify(
+            m => m.HandleMessage(It.IsAny<WebSocket>(), It.IsAny<string>()),
+            Times.Never
+        );
+        socketMock.Verify(
+            s =>
+                s.CloseAsync(
+                    WebSocketCloseStatus.MessageTooBig,
+                    It.IsAny<string>(),
+                    It.IsAny<CancellationToken>()
+                ),
+            Times.Once
+        );
+    }
+
     [Test]
     public async Task HandleConnection_UnregisteredSocket_DisconnectsWithUnknownType()
     {
@@ -237,4 +381,133 @@ public class ConnectionHandlerTests
             Times.Once
         );
     }
+
+    [Test]
+    public async Task HandleConnection_GoingAwayClose_CountsDisconnectUnderItsCode()
+    {
+        var socketMock = new Mock<WebSocket>();
+        socketMock
+            .SetupSequence(s => s.State)
+            .Returns(WebSocketState.Open)
+            .Returns(WebSocketState.Closed);
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ReturnsAsync(new WebSocketReceiveResult(0, WebSocketMessageType.Close, true));
+        socketMock.Setup(s => s.CloseStatus).Returns(WebSocketCloseStatus.EndpointUnavailable);
+        var goingAwayBefore = SignalingMetrics.DisconnectsTotal.WithLabels("1001").Value;
+        var abnormalBefore = SignalingMetrics.DisconnectsTotal.WithLabels("1006").Value;
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                SignalingMetrics.DisconnectsTotal.WithLabels("1001").Value,
+                Is.EqualTo(goingAwayBefore + 1)
+            );
+            Assert.That(
+                SignalingMetrics.DisconnectsTotal.WithLabels("1006").Value,
+                Is.EqualTo(abnormalBefore)
+            );
+        });
+    }
+
+    [Test]
+    public async Task HandleConnection_ConnectionDropped_CountsAbnormalClosure()
+    {
+        var socketMock = new Mock<WebSocket>();
+        socketMock.Setup(s => s.State).Returns(WebSocketState.Open);
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ThrowsAsync(new WebSocketException(WebSocketError.ConnectionClosedPrematurely));
+        var abnormalBefore = SignalingMetrics.DisconnectsTotal.WithLabels("1006").Value;
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None);
+
+        Assert.That(
+            SignalingMetrics.DisconnectsTotal.WithLabels("1006").Value,
+            Is.EqualTo(abnormalBefore + 1)
+        );
+    }
+
+    [Test]
+    public async Task HandleConnection_ContinuesPropagatedTrace()
+    {
+        using var collector = new SpanCollector();
+        var parent = ActivityContext.Parse(
+            "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+            null
+        );
+        var socketMock = new Mock<WebSocket>();
+        socketMock.Setup(s => s.State).Returns(WebSocketState.Open);
+        socketMock
+            .Setup(s =>
+                s.ReceiveAsync(It.IsAny<ArraySegment<byte>>(), It.IsAny<CancellationToken>())
+            )
+            .ReturnsAsync(new WebSocketReceiveResult(0, WebSocketMessageType.Close, true));
+
+        await _handler.HandleConnection(socketMock.Object, CancellationToken.None, parent);
+
+        var span = collector.Spans.Single(s => s.OperationName == "signaling.connection");
+        Assert.Multiple(() =>
+        {
+            Assert.That(span.TraceId, Is.EqualTo(parent.TraceId));
+            Assert.That(span.ParentSpanId, Is.EqualTo(parent.SpanId));
+        });
+    }
+
+    [Test]
+    public async Task HandleConnection_MessagesBeyondBurst_AreRejectedAsRateLimited()
+    {
+        var handler = new ConnectionHandler(
+            _messageHandlerMock.Object,
+            _signalRegistryMock.Object,
+            _loggerMock.Object,
+            new MessageRateLimitOptions(MessagesPerSecond: 1, Burst: 3, MaxViolations: 0)
+        );
+        var socket = new FloodingWebSocket(messageCount: 5);
+        var rateLimitedBefore = SignalingMetrics.RateLimitedMessages.Value;
+
+        await handler.HandleConnection(socket, CancellationToken.None);
+
+        var errors = socket
+            .SentMessages.Select(m => JsonSerializer.Deserialize<SignalErrorResponse>(m)!)
+            .ToList();
+        _messageHandlerMock.Verify(m => m.HandleMessage(socket, "{}"), Times.Exactly(3));
+        Assert.Multiple(() =>
+        {
+            Assert.That(errors.Select(e => e.Code), Is.All.EqualTo(SignalErrorCodes.RateLimited));
+            Assert.That(errors, Has.Count.EqualTo(2));
+            Assert.That(
+                SignalingMetrics.RateLimitedMessages.Value,
+                Is.EqualTo(rateLimitedBefore + 2)
+            );
+            Assert.That(socket.SentCloseStatus, Is.Null);
+        });
+    }
+
+    [Test]
+    public async Task HandleConnection_RepeatedRateLimitViolations_CloseTheConnection()
+    {
+        var handler = new ConnectionHandler(
+            _messageHandlerMock.Object,
+            _signalRegistryMock.Object,
+            _loggerMock.Object,
+            new MessageRateLimitOptions(MessagesPerSecond: 1, Burst: 1, MaxViolations: 2)
+        );
+        var socket = new FloodingWebSocket(messageCount: 10);
+
+        await handler.HandleConnection(socket, CancellationToken.None);
+
+        _messageHandlerMock.Verify(m => m.HandleMessage(socket, "{}"), Times.Once);
+        Assert.Multiple(() =>
+        {
+            Assert.That(socket.SentCloseStatus, Is.EqualTo(WebSocketCloseStatus.PolicyViolation));
+            Assert.That(socket.SentMessages, Has.Count.EqualTo(2));
+        });
+    }
 }
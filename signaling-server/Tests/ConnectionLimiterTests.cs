@@ -0,0 +1,61 @@
+using Microsoft.AspNetCore.Http;
+using SignalingServer.Endpoints;
+using SignalingServer.Services;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class ConnectionLimiterTests
+{
+    [Test]
+    public void TryAcquire_RejectsOnceEverySlotIsTaken()
+    {
+        var limiter = new ConnectionLimiter(maxConnections: 2);
+
+        var results = Enumerable.Range(0, 3).Select(_ => limiter.TryAcquire()).ToList();
+
+        Assert.That(results, Is.EqualTo(new[] { true, true, false }));
+    }
+
+    [Test]
+    public void Release_FreesASlotForTheNextConnection()
+    {
+        var limiter = new ConnectionLimiter(maxConnections: 1);
+        limiter.TryAcquire();
+
+        limiter.Release();
+
+        Assert.That(limiter.TryAcquire(), Is.True);
+    }
+
+    [Test]
+    public void TryAcquire_WithNoLimit_AlwaysSucceeds()
+    {
+        var limiter = new ConnectionLimiter(maxConnections: 0);
+
+        Assert.That(Enumerable.Range(0, 100).All(_ => limiter.TryAcquire()), Is.True);
+    }
+
+    [Test]
+    public void TryAcquireConnectionSlot_AtCapacity_Returns503WithRetryAfter()
+    {
+        var limiter = new ConnectionLimiter(maxConnections: 1);
+        var first = new DefaultHttpContext();
+        var second = new DefaultHttpContext();
+
+        var firstAccepted = WebSocketEndpoints.TryAcquireConnectionSlot(first, limiter);
+        var secondAccepted = WebSocketEndpoints.TryAcquireConnectionSlot(second, limiter);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(firstAccepted, Is.True);
+            Assert.That(first.Response.StatusCode, Is.EqualTo(StatusCodes.Status200OK));
+            Assert.That(secondAccepted, Is.False);
+            Assert.That(
+                second.Response.StatusCode,
+                Is.EqualTo(StatusCodes.Status503ServiceUnavailable)
+            );
+            Assert.That(second.Response.Headers.RetryAfter.ToString(), Is.Not.Empty);
+        });
+    }
+}
@@ -0,0 +1,88 @@
+using System.Net;
+using Microsoft.AspNetCore.Http;
+using SignalingServer.Configuration;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class ConnectionRateLimitingTests
+{
+    private static HttpContext CreateContext(string remoteIp, string? forwardedFor = null)
+    {
+        var context = new DefaultHttpContext();
+        context.Connection.RemoteIpAddress = IPAddress.Parse(remoteIp);
+        if (forwardedFor != null)
+            context.Request.Headers["X-Forwarded-For"] = forwardedFor;
+        return context;
+    }
+
+    [Test]
+    public void Partition_RejectsConnectionsOnceBurstIsExhausted()
+    {
+        var partition = ConnectionRateLimiting.CreatePartition(
+            CreateContext("203.0.113.7"),
+            connectionsPerMinute: 1,
+            burst: 3,
+            trustForwardedHeaders: false
+        );
+        using var limiter = partition.Factory(partition.PartitionKey);
+
+        var results = Enumerable.Range(0, 4).Select(_ => limiter.AttemptAcquire().IsAcquired);
+
+        Assert.That(results, Is.EqualTo(new[] { true, true, true, false }));
+    }
+
+    [Test]
+    public void Partition_IsKeyedByRemoteAddress()
+    {
+        var partition = ConnectionRateLimiting.CreatePartition(
+            CreateContext("203.0.113.7", forwardedFor: "198.51.100.1"),
+            connectionsPerMinute: 1,
+            burst: 1,
+            trustForwardedHeaders: false
+        );
+
+        Assert.That(partition.PartitionKey, Is.EqualTo("203.0.113.7"));
+    }
+
+    [Test]
+    public void Partition_HonorsForwardedForWhenTrusted()
+    {
+        var partition = ConnectionRateLimiting.CreatePartition(
+            CreateContext("10.0.0.1", forwardedFor: "198.51.100.1"),
+            connectionsPerMinute: 1,
+            burst: 1,
+            trustForwardedHeaders: true
+        );
+
+        Assert.That(partition.PartitionKey, Is.EqualTo("198.51.100.1"));
+    }
+
+    [Test]
+    public void Partition_IgnoresSpoofedLeftMostForwardedFor()
+    {
+        // The client sent the first entry itself; the proxy appended the address it saw
+        var partition = ConnectionRateLimiting.CreatePartition(
+            CreateContext("10.0.0.1", forwardedFor: "192.0.2.66, 198.51.100.1"),
+            connectionsPerMinute: 1,
+            burst: 1,
+            trustForwardedHeaders: true
+        );
+
+        Assert.That(partition.PartitionKey, Is.EqualTo("198.51.100.1"));
+    }
+
+    [Test]
+    public void Partition_SkipsEntriesAddedByTrustedProxies()
+    {
+        var partition = ConnectionRateLimiting.CreatePartition(
+            CreateContext("10.0.0.2", forwardedFor: "192.0.2.66, 198.51.100.1, 10.0.0.1"),
+            connectionsPerMinute: 1,
+            burst: 1,
+            trustForwardedHeaders: true,
+            trustedProxyHops: 2
+        );
+
+        Assert.That(partition.PartitionKey, Is.EqualTo("198.51.100.1"));
+    }
+}
@@ -0,0 +1,8 @@
+namespace SignalingServer.Tests.Helpers;
+
+public class FixedTimeProvider(DateTimeOffset now) : TimeProvider
+{
+    public DateTimeOffset Now { get; set; } = now;
+
+    public override DateTimeOffset GetUtcNow() => Now;
+}
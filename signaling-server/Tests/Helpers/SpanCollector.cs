@@ -0,0 +1,31 @@
+using System.Collections.Concurrent;
+using System.Diagnostics;
+using SignalingServer.Services;
+
+namespace SignalingServer.Tests.Helpers;
+
+/// <summary>
+/// In-memory stand-in for a trace exporter: records every finished span of the server's
+/// activity source while it is alive.
+/// </summary>
+public sealed class SpanCollector : IDisposable
+{
+    private readonly ConcurrentQueue<Activity> _spans = new();
+    private readonly ActivityListener _listener;
+
+    public SpanCollector()
+    {
+        _listener = new ActivityListener
+        {
+            ShouldListenTo = source => source.Name == SignalingTracing.SourceName,
+            Sample = (ref ActivityCreationOptions<ActivityContext> _) =>
+                ActivitySamplingResult.AllDataAndRecorded,
+            ActivityStopped = _spans.Enqueue,
+        };
+        ActivitySource.AddActivityListener(_listener);
+    }
+
+    public IReadOnlyList<Activity> Spans => _spans.ToArray();
+
+    public void Dispose() => _listener.Dispose();
+}
@@ -0,0 +1,35 @@
+using System.Net;
+
+namespace SignalingServer.Tests.Helpers;
+
+/// <summary>
+/// Answers requests with the given JSON bodies in turn, repeating the last one, and counts how
+/// many requests were made. While <see cref="FailuresRemaining"/> is above zero, requests fail
+/// with 503 instead.
+/// </summary>
+public class StubHttpMessageHandler(params string[] responseBodies) : HttpMessageHandler
+{
+    private int _answered;
+
+    public int RequestCount { get; private set; }
+
+    public int FailuresRemaining { get; set; }
+
+    protected override Task<HttpResponseMessage> SendAsync(
+        HttpRequestMessage request,
+        CancellationToken cancellationToken
+    )
+    {
+        RequestCount++;
+        if (FailuresRemaining > 0)
+        {
+            FailuresRemaining--;
+            return Task.FromResult(new HttpResponseMessage(HttpStatusCode.ServiceUnavailable));
+        }
+
+        var responseBody = responseBodies[Math.Min(_answered++, responseBodies.Length - 1)];
+        return Task.FromResult(
+            new HttpResponseMessage(HttpStatusCode.OK) { Content = new StringContent(responseBody) }
+        );
+    }
+}
@@ -5,6 +5,11 @@ namespace SignalingServer.Tests.Helpers;
 public class TestWebSocket : WebSocket
 {
     public List<string> SentMessages = new();
+    public List<byte[]> SentBinaryMessages = new();
+
+    // The close frame sent with CloseAsync or CloseOutputAsync, if any
+    public WebSocketCloseStatus? SentCloseStatus { get; private set; }
+    public string? SentCloseDescription { get; private set; }
 
     public override Task SendAsync(
         ArraySegment<byte> buffer,
@@ -13,6 +18,12 @@ public class TestWebSocket : WebSocket
         CancellationToken cancellationToken
     )
     {
+        if (messageType == WebSocketMessageType.Binary)
+        {
+            SentBinaryMessages.Add(buffer.ToArray());
+            return Task.CompletedTask;
+        }
+
         var json = System.Text.Encoding.UTF8.GetString(buffer.Array!, buffer.Offset, buffer.Count);
         SentMessages.Add(json);
         return Task.CompletedTask;
@@ -30,13 +41,23 @@ public class TestWebSocket : WebSocket
         WebSocketCloseStatus closeStatus,
         string? statusDescription,
         CancellationToken cancellationToken
-    ) => Task.CompletedTask;
+    )
+    {
+        SentCloseStatus = closeStatus;
+        SentCloseDescription = statusDescription;
+        return Task.CompletedTask;
+    }
 
     public override Task CloseOutputAsync(
         WebSocketCloseStatus closeStatus,
         string? statusDescription,
         CancellationToken cancellationToken
-    ) => Task.CompletedTask;
+    )
+    {
+        SentCloseStatus = closeStatus;
+        SentCloseDescription = statusDescription;
+        return Task.CompletedTask;
+    }
 
     public override void Dispose() { }
 
@@ -0,0 +1,71 @@
+using Microsoft.Extensions.Logging;
+using Moq;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class IdleRoomSweeperTests
+{
+    private FixedTimeProvider _time;
+    private SignalRegistry _registry;
+    private IdleRoomSweeper _sweeper;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _time = new FixedTimeProvider(DateTimeOffset.FromUnixTimeSeconds(1700000000));
+        _registry = new SignalRegistry(new Mock<ILogger<SignalRegistry>>().Object, _time);
+        _sweeper = new IdleRoomSweeper(
+            _registry,
+            idleTimeout: TimeSpan.FromMinutes(10),
+            sweepInterval: TimeSpan.FromMinutes(1),
+            _time,
+            new Mock<ILogger<IdleRoomSweeper>>().Object
+        );
+    }
+
+    [Test]
+    public async Task SweepAsync_RoomIdlePastTtl_ClosesAndRemovesAllMembers()
+    {
+        var hostSocket = new TestWebSocket();
+        var clientSocket = new TestWebSocket();
+        _registry.RegisterHost("IDLE01", hostSocket);
+        _registry.RegisterClient("CLI001", clientSocket, "IDLE01");
+
+        _time.Now = _time.Now.AddMinutes(11);
+        var closed = await _sweeper.SweepAsync();
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(closed, Is.EqualTo(1));
+            Assert.That(_registry.TryGetHostSocket("IDLE01", out _), Is.False);
+            Assert.That(_registry.TryGetClientSocket("CLI001", out _), Is.False);
+            Assert.That(hostSocket.SentCloseDescription, Is.EqualTo("room-expired"));
+            Assert.That(clientSocket.SentCloseDescription, Is.EqualTo("room-expired"));
+        });
+    }
+
+    [Test]
+    public async Task SweepAsync_RecentClientActivity_KeepsRoomOpen()
+    {
+        var hostSocket = new TestWebSocket();
+        var clientSocket = new TestWebSocket();
+        _registry.RegisterHost("BUSY01", hostSocket);
+        _registry.RegisterClient("CLI001", clientSocket, "BUSY01");
+
+        _time.Now = _time.Now.AddMinutes(9);
+        _registry.RecordActivity(clientSocket);
+        _time.Now = _time.Now.AddMinutes(9);
+
+        var closed = await _sweeper.SweepAsync();
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(closed, Is.EqualTo(0));
+            Assert.That(_registry.TryGetHostSocket("BUSY01", out _), Is.True);
+            Assert.That(hostSocket.SentCloseDescription, Is.Null);
+        });
+    }
+}
@@ -0,0 +1,238 @@
+using System.Security.Cryptography;
+using System.Text;
+using Microsoft.Extensions.Logging;
+using Microsoft.IdentityModel.JsonWebTokens;
+using Microsoft.IdentityModel.Tokens;
+using Moq;
+using SignalingServer.Configuration;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class JwtAuthenticatorTests
+{
+    private const string HmacSecret = "test-secret-that-is-at-least-32-bytes-long";
+
+    private Mock<ILogger<JwtAuthenticator>> _logger;
+    private FixedTimeProvider _time;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _logger = new Mock<ILogger<JwtAuthenticator>>();
+        _time = new FixedTimeProvider(DateTimeOffset.UtcNow);
+    }
+
+    private JwtAuthenticator CreateAuthenticator(
+        string? hmacSecret = HmacSecret,
+        string? jwksUrl = null,
+        HttpMessageHandler? httpHandler = null
+    )
+    {
+        var options = new JwtAuthOptions(hmacSecret, jwksUrl, null, null, TimeSpan.FromMinutes(10));
+        return new JwtAuthenticator(
+            options,
+            new HttpClient(httpHandler ?? new StubHttpMessageHandler("{}")),
+            _time,
+            _logger.Object
+        );
+    }
+
+    private static string CreateToken(
+        SecurityKey key,
+        string algorithm,
+        DateTime expires,
+        params string[] rooms
+    )
+    {
+        return new JsonWebTokenHandler().CreateToken(
+            new SecurityTokenDescriptor
+            {
+                Claims = new Dictionary<string, object> { [JwtAuthenticator.RoomsClaim] = rooms },
+                IssuedAt = expires.AddHours(-2),
+                NotBefore = expires.AddHours(-2),
+                Expires = expires,
+                SigningCredentials = new SigningCredentials(key, algorithm),
+            }
+        );
+    }
+
+    // A JWKS document publishing the public half of each key under its key id
+    private static string CreateJwks(params RsaSecurityKey[] keys)
+    {
+        var entries = keys.Select(key =>
+        {
+            var parameters = key.Rsa.ExportParameters(false);
+            return $$"""
+                {"kty":"RSA","use":"sig","alg":"RS256","kid":"{{key.KeyId}}",
+                "n":"{{Base64UrlEncoder.Encode(parameters.Modulus)}}",
+                "e":"{{Base64UrlEncoder.Encode(parameters.Exponent)}}"}
+                """;
+        });
+        return $$"""{"keys":[{{string.Join(",", entries)}}]}""";
+    }
+
+    private static string CreateRsaToken(RsaSecurityKey key) =>
+        CreateToken(key, SecurityAlgorithms.RsaSha256, DateTime.UtcNow.AddHours(1), "HOST01");
+
+    private static string CreateHmacToken(string secret, DateTime expires, params string[] rooms)
+    {
+        var key = new SymmetricSecurityKey(Encoding.UTF8.GetBytes(secret));
+        return CreateToken(key, SecurityAlgorithms.HmacSha256, expires, rooms);
+    }
+
+    [Test]
+    public void IsEnabled_WithoutSecretOrJwks_ReturnsFalse()
+    {
+        Assert.That(CreateAuthenticator(hmacSecret: null).IsEnabled, Is.False);
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_ValidHmacToken_ReturnsAllowedHosts()
+    {
+        var token = CreateHmacToken(HmacSecret, DateTime.UtcNow.AddHours(1), "HOST01", "HOST02");
+
+        var identity = await CreateAuthenticator().AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.That(identity, Is.Not.Null);
+        Assert.That(
+            JwtAuthenticator.GetAllowedHosts(identity!),
+            Is.EquivalentTo(new[] { "HOST01", "HOST02" })
+        );
+    }
+
+    [Test]
+    public async Task GetAllowedHosts_WithoutRoomsClaim_ReturnsNull()
+    {
+        var token = CreateHmacToken(HmacSecret, DateTime.UtcNow.AddHours(1));
+
+        var identity = await CreateAuthenticator().AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.That(JwtAuthenticator.GetAllowedHosts(identity!), Is.Null);
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_MissingToken_ReturnsNull()
+    {
+        var identity = await CreateAuthenticator().AuthenticateAsync(null, CancellationToken.None);
+        Assert.That(identity, Is.Null);
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_WrongSecret_ReturnsNull()
+    {
+        var token = CreateHmacToken(
+            "another-secret-that-is-at-least-32-bytes",
+            DateTime.UtcNow.AddHours(1),
+            "HOST01"
+        );
+
+        var identity = await CreateAuthenticator().AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.That(identity, Is.Null);
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_ExpiredToken_ReturnsNull()
+    {
+        var token = CreateHmacToken(HmacSecret, DateTime.UtcNow.AddHours(-1), "HOST01");
+
+        var identity = await CreateAuthenticator().AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.That(identity, Is.Null);
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_JwksSignedToken_CachesKeysUntilRefreshInterval()
+    {
+        using var rsa = RSA.Create(2048);
+        var key = new RsaSecurityKey(rsa) { KeyId = "key-1" };
+        var httpHandler = new StubHttpMessageHandler(CreateJwks(key));
+        var authenticator = CreateAuthenticator(
+            hmacSecret: null,
+            jwksUrl: "https://auth.example.com/jwks.json",
+            httpHandler: httpHandler
+        );
+
+        var token = CreateRsaToken(key);
+
+        Assert.That(
+            await authenticator.AuthenticateAsync(token, CancellationToken.None),
+            Is.Not.Null
+        );
+        Assert.That(
+            await authenticator.AuthenticateAsync(token, CancellationToken.None),
+            Is.Not.Null
+        );
+        Assert.That(httpHandler.RequestCount, Is.EqualTo(1));
+
+        _time.Now = _time.Now.AddMinutes(11);
+        await authenticator.AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.That(httpHandler.RequestCount, Is.EqualTo(2));
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_JwksFetchFailsOnce_RetriesAfterBackoff()
+    {
+        using var rsa = RSA.Create(2048);
+        var key = new RsaSecurityKey(rsa) { KeyId = "key-1" };
+        var httpHandler = new StubHttpMessageHandler(CreateJwks(key)) { FailuresRemaining = 1 };
+        var authenticator = CreateAuthenticator(
+            hmacSecret: null,
+            jwksUrl: "https://auth.example.com/jwks.json",
+            httpHandler: httpHandler
+        );
+        var token = CreateRsaToken(key);
+
+        var duringOutage = await authenticator.AuthenticateAsync(token, CancellationToken.None);
+        var withinBackoff = await authenticator.AuthenticateAsync(token, CancellationToken.None);
+        var requestsDuringBackoff = httpHandler.RequestCount;
+
+        // Well inside the refresh interval, which a failed fetch must not start
+        _time.Now = _time.Now.AddMinutes(1);
+        var afterBackoff = await authenticator.AuthenticateAsync(token, CancellationToken.None);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(duringOutage, Is.Null);
+            Assert.That(withinBackoff, Is.Null);
+            Assert.That(requestsDuringBackoff, Is.EqualTo(1));
+            Assert.That(afterBackoff, Is.Not.Null);
+            Assert.That(httpHandler.RequestCount, Is.EqualTo(2));
+        });
+    }
+
+    [Test]
+    public async Task AuthenticateAsync_TokenWithUncachedKeyId_RefetchesJwksOnce()
+    {
+        using var oldRsa = RSA.Create(2048);
+        using var newRsa = RSA.Create(2048);
+        var oldKey = new RsaSecurityKey(oldRsa) { KeyId = "key-1" };
+        var newKey = new RsaSecurityKey(newRsa) { KeyId = "key-2" };
+        var httpHandler = new StubHttpMessageHandler(
+            CreateJwks(oldKey),
+            CreateJwks(oldKey, newKey)
+        );
+        var authenticator = CreateAuthenticator(
+            hmacSecret: null,
+            jwksUrl: "https://auth.example.com/jwks.json",
+            httpHandler: httpHandler
+        );
+
+        await authenticator.AuthenticateAsync(CreateRsaToken(oldKey), CancellationToken.None);
+        _time.Now = _time.Now.AddMinutes(1);
+        var identity = await authenticator.AuthenticateAsync(
+            CreateRsaToken(newKey),
+            CancellationToken.None
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(identity, Is.Not.Null);
+            Assert.That(httpHandler.RequestCount, Is.EqualTo(2));
+        });
+    }
+}
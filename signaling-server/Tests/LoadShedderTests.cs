@@ -0,0 +1,66 @@
+using Microsoft.AspNetCore.Http;
+using SignalingServer.Endpoints;
+using SignalingServer.Services;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class LoadShedderTests
+{
+    [TestCase(10, 10)]
+    [TestCase(10, 12)]
+    [TestCase(10, 0)]
+    public void Constructor_RejectsLowWaterMarkNotBelowHigh(int high, int low)
+    {
+        Assert.Throws<ArgumentOutOfRangeException>(() => new LoadShedder(high, low));
+    }
+
+    [Test]
+    public void Constructor_WithoutHighWaterMark_DisablesShedding()
+    {
+        var shedder = new LoadShedder(highWaterMark: 0, lowWaterMark: 0);
+
+        shedder.Update(1_000_000);
+
+        Assert.That(shedder.IsShedding, Is.False);
+    }
+
+    [Test]
+    public void TryAcquireConnectionSlot_ShedsAboveHighUntilBelowLowWaterMark()
+    {
+        var shedder = new LoadShedder(highWaterMark: 4, lowWaterMark: 2);
+        var limiter = new ConnectionLimiter(maxConnections: 0, shedder);
+
+        // Drive the count past the high-water mark
+        for (var i = 0; i < 5; i++)
+        {
+            Assert.That(limiter.TryAcquire(), Is.True);
+        }
+
+        var shed = new DefaultHttpContext();
+        var shedAccepted = WebSocketEndpoints.TryAcquireConnectionSlot(shed, limiter);
+
+        // Back under the high mark but not yet under the low one: still shedding
+        limiter.Release();
+        limiter.Release();
+        limiter.Release();
+        var stillShedding = limiter.TryAcquire();
+
+        limiter.Release();
+        var recovered = new DefaultHttpContext();
+        var recoveredAccepted = WebSocketEndpoints.TryAcquireConnectionSlot(recovered, limiter);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(shedAccepted, Is.False);
+            Assert.That(
+                shed.Response.StatusCode,
+                Is.EqualTo(StatusCodes.Status503ServiceUnavailable)
+            );
+            Assert.That(stillShedding, Is.False);
+            Assert.That(recoveredAccepted, Is.True);
+            Assert.That(recovered.Response.StatusCode, Is.EqualTo(StatusCodes.Status200OK));
+            Assert.That(shedder.IsShedding, Is.False);
+        });
+    }
+}
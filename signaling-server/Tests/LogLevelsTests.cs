@@ -0,0 +1,35 @@
+using Serilog.Events;
+using SignalingServer.Configuration;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class LogLevelsTests
+{
+    [TestCase("Information", LogEventLevel.Information)]
+    [TestCase("warning", LogEventLevel.Warning)]
+    [TestCase("info", LogEventLevel.Information)]
+    [TestCase("WARN", LogEventLevel.Warning)]
+    [TestCase("err", LogEventLevel.Error)]
+    [TestCase("trace", LogEventLevel.Verbose)]
+    [TestCase("debug", LogEventLevel.Debug)]
+    [TestCase("crit", LogEventLevel.Fatal)]
+    [TestCase("critical", LogEventLevel.Fatal)]
+    [TestCase("fatal", LogEventLevel.Fatal)]
+    [TestCase(" info ", LogEventLevel.Information)]
+    public void TryParse_KnownNameOrAlias_ReturnsLevel(string value, LogEventLevel expected)
+    {
+        Assert.That(LogLevels.TryParse(value, out var level), Is.True);
+        Assert.That(level, Is.EqualTo(expected));
+    }
+
+    [TestCase(null)]
+    [TestCase("")]
+    [TestCase("loud")]
+    [TestCase("3")]
+    [TestCase("42")]
+    public void TryParse_UnknownValue_ReturnsFalse(string? value)
+    {
+        Assert.That(LogLevels.TryParse(value, out _), Is.False);
+    }
+}
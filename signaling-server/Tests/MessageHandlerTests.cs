@@ -2,6 +2,9 @@ using System.Net.WebSockets;
 using System.Text.Json;
 using Microsoft.Extensions.Logging;
 using Moq;
+using SignalingServer.Extensions;
+using SignalingServer.Helpers;
+using SignalingServer.Middleware;
 using SignalingServer.Models;
 using SignalingServer.Services;
 using SignalingServer.Tests.Helpers;
@@ -13,6 +16,7 @@ public class MessageHandlerTests
 {
     private Mock<ISignalRegistry> _registry;
     private Mock<ILogger<MessageHandler>> _logger;
+    private SessionTokenService _sessionTokens;
     private MessageHandler _handler;
     private Mock<WebSocket> _socket;
 
@@ -20,9 +24,20 @@ public class MessageHandlerTests
     public void SetUp()
     {
         _registry = new Mock<ISignalRegistry>();
+        _registry
+            .Setup(r => r.IsJoinSecretValid(It.IsAny<string>(), It.IsAny<string?>()))
+            .Returns(true);
+        _registry
+            .Setup(r => r.GetNamespace(It.IsAny<WebSocket>()))
+            .Returns(SignalNamespaces.Default);
         _logger = new Mock<ILogger<MessageHandler>>();
         _socket = new Mock<WebSocket>();
-        _handler = new MessageHandler(_registry.Object, _logger.Object);
+        _sessionTokens = new SessionTokenService(
+            "test-secret"u8.ToArray(),
+            TimeSpan.FromMinutes(5),
+            TimeProvider.System
+        );
+        _handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
     }
 
     // ──────────────── LOGIC/ERROR TESTS ────────────────
@@ -178,6 +193,99 @@ public class MessageHandlerTests
         _logger.VerifyLog(LogLevel.Warning, "Validation failed:", Times.Once());
     }
 
+    [TestCase("{ invalid }", SignalErrorCodes.BadMessage)]
+    [TestCase("{\"type\":\"join-host\"}", SignalErrorCodes.BadMessage)]
+    [TestCase("{\"type\":\"unknown\"}", SignalErrorCodes.UnknownType)]
+    [TestCase("{\"type\":\"join-host\",\"hostId\":\"host404\"}", SignalErrorCodes.HostNotFound)]
+    [TestCase("{\"type\":\"msg-to-host\",\"payload\":\"hi\"}", SignalErrorCodes.NotRegistered)]
+    [TestCase(
+        "{\"type\":\"msg-to-client\",\"clientId\":\"c1\",\"payload\":\"hi\"}",
+        SignalErrorCodes.NotRegistered
+    )]
+    [TestCase("{\"type\":\"set-metadata\",\"metadata\":{}}", SignalErrorCodes.NotRegistered)]
+    public async Task RejectedMessage_SendsErrorWithCode(string raw, string expectedCode)
+    {
+        var socket = new TestWebSocket();
+
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.Type, Is.EqualTo(SignalMessageTypes.Error));
+            Assert.That(response?.Code, Is.EqualTo(expectedCode));
+            Assert.That(response?.Message, Is.Not.Empty);
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_WithInvalidRoomId_RejectsBeforeLookup()
+    {
+        var socket = new TestWebSocket();
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room 1; DROP" }
+        );
+
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.InvalidRoomId));
+        _registry.Verify(
+            r => r.TryGetHostSocket(It.IsAny<string>(), out It.Ref<WebSocket>.IsAny!),
+            Times.Never
+        );
+    }
+
+    [Test]
+    public async Task ValidationFailure_ListsErrorsInDetailAndEchoesRequestId()
+    {
+        var socket = new TestWebSocket();
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.MsgToClient, RequestId = "req-1" }
+        );
+
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.BadMessage));
+            Assert.That(response?.RequestId, Is.EqualTo("req-1"));
+            Assert.That(response?.Detail, Does.Contain("ClientId is required"));
+            Assert.That(response?.Detail, Does.Contain("Payload is required"));
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_WithOversizedMetadata_SendsMetadataTooLarge()
+    {
+        var socket = new TestWebSocket();
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room123",
+                Metadata = JsonSerializer.SerializeToElement(new { name = new string('x', 2048) }),
+            }
+        );
+
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.MetadataTooLarge));
+    }
+
+    [Test]
+    public async Task HandleBinaryMessage_FromUnregisteredSocket_SendsNotRegistered()
+    {
+        var socket = new TestWebSocket();
+
+        await _handler.HandleBinaryMessage(socket, BinaryEnvelope.Create("HOST01", [1]));
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.NotRegistered));
+    }
+
     // ──────────────── FUNCTIONAL TESTS ────────────────
 
     [Test]
@@ -187,10 +295,10 @@ public class MessageHandlerTests
         _registry.Setup(r => r.GenerateUniqueHostIdAsync()).ReturnsAsync("host-abc");
 
         var raw = JsonSerializer.Serialize(new SignalMessage { Type = SignalMessageTypes.Host });
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(socket, raw);
 
-        _registry.Verify(r => r.RegisterHost("host-abc", socket, 10), Times.Once);
+        _registry.Verify(r => r.RegisterHost("host-abc", socket, 10, null), Times.Once);
         var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
         Assert.Multiple(() =>
         {
@@ -199,6 +307,20 @@ public class MessageHandlerTests
         });
     }
 
+    [Test]
+    public async Task HostMessage_WithJoinSecret_ProtectsRoom()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.GenerateUniqueHostIdAsync()).ReturnsAsync("host-abc");
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.Host, JoinSecret = "hunter2" }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(r => r.RegisterHost("host-abc", socket, 10, "hunter2"), Times.Once);
+    }
+
     [Test]
     public async Task JoinHost_RegistersClient_AndSendsJoinConfirmation()
     {
@@ -215,16 +337,22 @@ public class MessageHandlerTests
             );
 
         _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-77");
-        _registry.Setup(r => r.RegisterClient("client-77", socket, "room123")).Returns(true);
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-77", socket, "room123", out existingClientIds))
+            .Returns(true);
 
         var raw = JsonSerializer.Serialize(
             new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room123" }
         );
 
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(socket, raw);
 
-        _registry.Verify(r => r.RegisterClient("client-77", socket, "room123"), Times.Once);
+        _registry.Verify(
+            r => r.RegisterClient("client-77", socket, "room123", out existingClientIds),
+            Times.Once
+        );
 
         var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
         Assert.Multiple(() =>
@@ -236,7 +364,77 @@ public class MessageHandlerTests
     }
 
     [Test]
-    public async Task JoinHost_RejectsClient_WhenHostAtCapacity()
+    public async Task JoinHost_SendsRoomStateWithExistingClients()
+    {
+        var socket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room123", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = new TestWebSocket();
+                    return true;
+                }
+            );
+
+        _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-3");
+        IReadOnlyList<string> existingClientIds = ["client-1", "client-2"];
+        _registry
+            .Setup(r => r.RegisterClient("client-3", socket, "room123", out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room123" }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        var roomState = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[1]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(roomState?.Type, Is.EqualTo(SignalMessageTypes.RoomState));
+            Assert.That(roomState?.ClientIds, Is.EqualTo(new[] { "client-1", "client-2" }));
+        });
+    }
+
+    [TestCase("AAAAAA", "client-1")]
+    [TestCase("ZZZZZZ", "client-1")]
+    public async Task JoinHost_TellsExactlyOnePeerToOffer(string hostId, string clientId)
+    {
+        var socket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+        _registry
+            .Setup(r => r.TryGetHostSocket(hostId, out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+        _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync(clientId);
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient(clientId, socket, hostId, out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = hostId }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        var joined = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        var clientJoined = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(joined?.ShouldOffer, Is.Not.Null);
+            Assert.That(clientJoined?.ShouldOffer, Is.Not.Null);
+            Assert.That(joined?.ShouldOffer, Is.Not.EqualTo(clientJoined?.ShouldOffer));
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_RejectsAndClosesClient_WhenHostAtCapacity()
     {
         var socket = new TestWebSocket();
 
@@ -251,25 +449,168 @@ public class MessageHandlerTests
             );
 
         _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-77");
-        _registry.Setup(r => r.RegisterClient("client-77", socket, "room123")).Returns(false); // Simulate capacity reached
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-77", socket, "room123", out existingClientIds))
+            .Returns(false); // Simulate capacity reached
 
         var raw = JsonSerializer.Serialize(
             new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room123" }
         );
 
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(socket, raw);
 
-        _registry.Verify(r => r.RegisterClient("client-77", socket, "room123"), Times.Once);
+        _registry.Verify(
+            r => r.RegisterClient("client-77", socket, "room123", out existingClientIds),
+            Times.Once
+        );
 
-        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
         Assert.Multiple(() =>
         {
             Assert.That(response?.Type, Is.EqualTo(SignalMessageTypes.Error));
-            Assert.That(response?.Payload, Is.EqualTo("Host is at capacity"));
+            Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.RoomFull));
+            Assert.That(response?.Message, Is.EqualTo("Host is at capacity"));
+            Assert.That(socket.SentCloseStatus, Is.EqualTo(WebSocketCloseStatus.PolicyViolation));
+            Assert.That(socket.SentCloseDescription, Is.EqualTo(SignalErrorCodes.RoomFull));
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_RejectsClient_WhenHostIsMigrating()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.IsMigrating("room123")).Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room123" }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.Migrating));
+    }
+
+    [Test]
+    public async Task JoinHost_RejectsClient_WhenJoinSecretDoesNotMatch()
+    {
+        var socket = new TestWebSocket();
+        _registry
+            .Setup(r => r.TryGetHostSocket("room123", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = new Mock<WebSocket>().Object;
+                    return true;
+                }
+            );
+        _registry.Setup(r => r.IsJoinSecretValid("room123", "wrong")).Returns(false);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room123",
+                JoinSecret = "wrong",
+                RequestId = "req-1",
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(
+            r =>
+                r.RegisterClient(
+                    It.IsAny<string>(),
+                    It.IsAny<WebSocket>(),
+                    It.IsAny<string>(),
+                    out It.Ref<IReadOnlyList<string>>.IsAny!
+                ),
+            Times.Never
+        );
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.BadSecret));
+            Assert.That(response?.RequestId, Is.EqualTo("req-1"));
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_RejectsClient_WhenTokenDoesNotAllowHost()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.IsHostAllowed(socket, "room123")).Returns(false);
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            authorizer: new TokenClaimsAuthorizer(_registry.Object)
+        );
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room123",
+                RequestId = "req-1",
+            }
+        );
+
+        await handler.HandleMessage(socket, raw);
+
+        _registry.Verify(
+            r =>
+                r.RegisterClient(
+                    It.IsAny<string>(),
+                    It.IsAny<WebSocket>(),
+                    It.IsAny<string>(),
+                    out It.Ref<IReadOnlyList<string>>.IsAny!
+                ),
+            Times.Never
+        );
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.Forbidden));
+            Assert.That(response?.RequestId, Is.EqualTo("req-1"));
         });
     }
 
+    [Test]
+    public async Task JoinHost_DeniedByAuthorizer_ClosesConnection()
+    {
+        _socket.Setup(s => s.State).Returns(WebSocketState.Open);
+        var authorizer = new Mock<IRoomAuthorizer>();
+        authorizer.Setup(a => a.CanJoin(_socket.Object, "room123")).Returns(false);
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            authorizer: authorizer.Object
+        );
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = "room123" }
+        );
+        await handler.HandleMessage(_socket.Object, raw);
+
+        _socket.Verify(
+            s =>
+                s.CloseOutputAsync(
+                    WebSocketCloseStatus.PolicyViolation,
+                    SignalErrorCodes.Forbidden,
+                    It.IsAny<CancellationToken>()
+                ),
+            Times.Once
+        );
+        _registry.Verify(
+            r => r.TryGetHostSocket(It.IsAny<string>(), out It.Ref<WebSocket>.IsAny!),
+            Times.Never
+        );
+    }
+
     [Test]
     public async Task MsgToHost_ForwardsToHostSocket()
     {
@@ -309,7 +650,7 @@ public class MessageHandlerTests
         var msg = new SignalMessage { Type = SignalMessageTypes.MsgToHost, Payload = "hello" };
 
         var raw = JsonSerializer.Serialize(msg);
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(clientSocket, raw);
 
         var forwarded = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
@@ -347,6 +688,7 @@ public class MessageHandlerTests
                     return true;
                 }
             );
+        SetupClientOfHost(clientSocket, "host42");
 
         var msg = new SignalMessage
         {
@@ -356,7 +698,7 @@ public class MessageHandlerTests
         };
 
         var raw = JsonSerializer.Serialize(msg);
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(hostSocket, raw);
 
         var forwarded = JsonSerializer.Deserialize<SignalMessage>(clientSocket.SentMessages[0]);
@@ -392,6 +734,7 @@ public class MessageHandlerTests
                     return true;
                 }
             );
+        SetupClientOfHost(clientA, "host123");
 
         var msg = new SignalMessage
         {
@@ -401,7 +744,7 @@ public class MessageHandlerTests
         };
 
         var raw = JsonSerializer.Serialize(msg);
-        var handler = new MessageHandler(_registry.Object, _logger.Object);
+        var handler = new MessageHandler(_registry.Object, _sessionTokens, _logger.Object);
         await handler.HandleMessage(hostSocket, raw);
 
         Assert.That(clientA.SentMessages, Has.Count.EqualTo(1));
@@ -413,4 +756,994 @@ public class MessageHandlerTests
             Assert.That(clientB.SentMessages, Is.Empty);
         });
     }
+
+    [Test]
+    public async Task MsgToClient_ForwardsConsecutiveMessagesInOrder()
+    {
+        var hostSocket = new TestWebSocket();
+        var clientSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostId(hostSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "host7";
+                    return true;
+                }
+            );
+
+        _registry
+            .Setup(r => r.TryGetClientSocket("client7", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = clientSocket;
+                    return true;
+                }
+            );
+        SetupClientOfHost(clientSocket, "host7");
+
+        var payloads = new[] { "candidate-1", "candidate-2", "candidate-3" };
+        foreach (var payload in payloads)
+        {
+            var raw = JsonSerializer.Serialize(
+                new SignalMessage
+                {
+                    Type = SignalMessageTypes.MsgToClient,
+                    ClientId = "client7",
+                    Payload = payload,
+                }
+            );
+            await _handler.HandleMessage(hostSocket, raw);
+        }
+
+        var received = clientSocket
+            .SentMessages.Select(m => JsonSerializer.Deserialize<SignalMessage>(m)?.Payload)
+            .ToList();
+        Assert.That(received, Is.EqualTo(payloads));
+    }
+
+    [Test]
+    public async Task MsgToClient_ClientNotFound_SendsPeerUnavailableError()
+    {
+        var hostSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostId(hostSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "host8";
+                    return true;
+                }
+            );
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.MsgToClient,
+                ClientId = "gone",
+                Payload = "candidate",
+            }
+        );
+        await _handler.HandleMessage(hostSocket, raw);
+
+        var error = JsonSerializer.Deserialize<SignalErrorResponse>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(error?.Type, Is.EqualTo(SignalMessageTypes.Error));
+            Assert.That(error?.Code, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+        });
+    }
+
+    [Test]
+    public async Task MsgToHost_HostGone_SendsPeerUnavailableError()
+    {
+        var clientSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetClientHost(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string hostId) =>
+                {
+                    hostId = "host9";
+                    return true;
+                }
+            );
+
+        _registry
+            .Setup(r => r.TryGetClientId(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string clientId) =>
+                {
+                    clientId = "clientB";
+                    return true;
+                }
+            );
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.MsgToHost, Payload = "candidate" }
+        );
+        await _handler.HandleMessage(clientSocket, raw);
+
+        var error = JsonSerializer.Deserialize<SignalErrorResponse>(clientSocket.SentMessages[0]);
+        Assert.That(error?.Code, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+    }
+
+    /// <summary>
+    /// A peer whose first send never completes, so anything queued after it stays buffered.
+    /// </summary>
+    private class StalledWebSocket : TestWebSocket
+    {
+        private readonly TaskCompletionSource _released = new(
+            TaskCreationOptions.RunContinuationsAsynchronously
+        );
+
+        public TaskCompletionSource SendStarted { get; } =
+            new(TaskCreationOptions.RunContinuationsAsynchronously);
+
+        public override async Task SendAsync(
+            ArraySegment<byte> buffer,
+            WebSocketMessageType messageType,
+            bool endOfMessage,
+            CancellationToken cancellationToken
+        )
+        {
+            SendStarted.TrySetResult();
+            await _released.Task;
+            throw new WebSocketException(WebSocketError.ConnectionClosedPrematurely);
+        }
+
+        public override void Abort() => _released.TrySetResult();
+    }
+
+    private void SetupClientOfHost(WebSocket clientSocket, string hostId)
+    {
+        _registry
+            .Setup(r => r.TryGetClientHost(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = hostId;
+                    return true;
+                }
+            );
+    }
+
+    private void SetupHostWithClient(WebSocket hostSocket, string clientId, WebSocket? client)
+    {
+        _registry
+            .Setup(r => r.TryGetHostId(hostSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "hostAck";
+                    return true;
+                }
+            );
+
+        _registry
+            .Setup(r => r.TryGetClientSocket(clientId, out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = client!;
+                    return client != null;
+                }
+            );
+
+        if (client != null)
+        {
+            SetupClientOfHost(client, "hostAck");
+        }
+    }
+
+    private static string MsgToClientWithId(string clientId) =>
+        JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.MsgToClient,
+                ClientId = clientId,
+                Payload = "offer",
+                RequestId = "req-7",
+            }
+        );
+
+    [Test]
+    public async Task MsgToClient_WithRequestId_AcksAfterQueueingToTarget()
+    {
+        var hostSocket = new TestWebSocket();
+        var clientSocket = new TestWebSocket();
+        SetupHostWithClient(hostSocket, "client1", clientSocket);
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("client1"));
+
+        var report = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(clientSocket.SentMessages, Has.Count.EqualTo(1));
+            Assert.That(report?.Type, Is.EqualTo(SignalMessageTypes.Ack));
+            Assert.That(report?.RequestId, Is.EqualTo("req-7"));
+            Assert.That(report?.Reason, Is.Null);
+        });
+    }
+
+    [Test]
+    public async Task MsgToClient_WithRequestId_UnknownTarget_Nacks()
+    {
+        var hostSocket = new TestWebSocket();
+        SetupHostWithClient(hostSocket, "gone", null);
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("gone"));
+
+        var report = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages.Single());
+        Assert.Multiple(() =>
+        {
+            Assert.That(report?.Type, Is.EqualTo(SignalMessageTypes.Nack));
+            Assert.That(report?.RequestId, Is.EqualTo("req-7"));
+            Assert.That(report?.Reason, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+        });
+    }
+
+    [Test]
+    public async Task MsgToClient_WithRequestId_ClientOfAnotherHost_Nacks()
+    {
+        var hostSocket = new TestWebSocket();
+        var foreignClient = new TestWebSocket();
+        SetupHostWithClient(hostSocket, "foreign", foreignClient);
+        SetupClientOfHost(foreignClient, "otherHost");
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("foreign"));
+
+        var report = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages.Single());
+        Assert.Multiple(() =>
+        {
+            Assert.That(foreignClient.SentMessages, Is.Empty);
+            Assert.That(report?.Type, Is.EqualTo(SignalMessageTypes.Nack));
+            Assert.That(report?.Reason, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+        });
+    }
+
+    [Test]
+    public async Task MsgToClient_WithRequestId_TargetBufferFull_Nacks()
+    {
+        var hostSocket = new TestWebSocket();
+        var stalled = new StalledWebSocket();
+        var clientSocket = new BufferedWebSocket(stalled, capacity: 1);
+        SetupHostWithClient(hostSocket, "slow", clientSocket);
+
+        // One message is stuck in the writer and one fills the buffer
+        await clientSocket.SendRawAsync("stuck");
+        await stalled.SendStarted.Task;
+        await clientSocket.SendRawAsync("queued");
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("slow"));
+        stalled.Abort();
+
+        var report = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(clientSocket.IsDropped, Is.True);
+            Assert.That(report?.Type, Is.EqualTo(SignalMessageTypes.Nack));
+            Assert.That(report?.Reason, Is.EqualTo(SignalErrorCodes.SlowConsumer));
+        });
+    }
+
+    [Test]
+    public async Task MsgToClient_WithRequestId_PollQueueFull_Nacks()
+    {
+        var hostSocket = new TestWebSocket();
+        var clientSocket = new PollingWebSocket("poll-1", capacity: 1, TimeProvider.System);
+        SetupHostWithClient(hostSocket, "poller", clientSocket);
+
+        // The peer hasn't polled, so its queue is already full
+        await clientSocket.SendRawAsync("queued");
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("poller"));
+
+        var report = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(clientSocket.IsDropped, Is.True);
+            Assert.That(report?.Type, Is.EqualTo(SignalMessageTypes.Nack));
+            Assert.That(report?.Reason, Is.EqualTo(SignalErrorCodes.SlowConsumer));
+        });
+    }
+
+    [Test]
+    public async Task HostMessage_WithValidReconnectToken_ReclaimsHostId()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.RegisterHost("host-old", socket, 10, null)).Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.Host,
+                ReconnectToken = _sessionTokens.Issue(PeerRole.Host, "host-old", "host-old"),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(r => r.GenerateUniqueHostIdAsync(), Times.Never);
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.HostId, Is.EqualTo("host-old"));
+            Assert.That(response?.ReconnectToken, Is.Not.Null);
+        });
+    }
+
+    [Test]
+    public async Task HostMessage_WithReconnectTokenFromAnotherNamespace_IssuesNewHostId()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.GetNamespace(socket)).Returns("app-b");
+        _registry.Setup(r => r.RegisterHost(It.IsAny<string>(), socket, 10, null)).Returns(true);
+        _registry.Setup(r => r.GenerateUniqueHostIdAsync()).ReturnsAsync("host-new");
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.Host,
+                ReconnectToken = _sessionTokens.Issue(
+                    PeerRole.Host,
+                    "host-old",
+                    "host-old",
+                    "app-a"
+                ),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(r => r.RegisterHost("host-old", socket, 10, null), Times.Never);
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.That(response?.HostId, Is.EqualTo("host-new"));
+    }
+
+    [Test]
+    public async Task JoinHost_WithValidReconnectToken_ReclaimsClientId()
+    {
+        var socket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room9", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = new TestWebSocket();
+                    return true;
+                }
+            );
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-old", socket, "room9", out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room9",
+                ReconnectToken = _sessionTokens.Issue(PeerRole.Client, "client-old", "room9"),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(r => r.GenerateUniqueClientIdAsync(), Times.Never);
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.That(response?.ClientId, Is.EqualTo("client-old"));
+    }
+
+    [Test]
+    public async Task JoinHost_WithTokenForAnotherHost_GetsNewClientId()
+    {
+        var socket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room9", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = new TestWebSocket();
+                    return true;
+                }
+            );
+        _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-new");
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-new", socket, "room9", out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room9",
+                ReconnectToken = _sessionTokens.Issue(PeerRole.Client, "client-old", "room1"),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.That(response?.ClientId, Is.EqualTo("client-new"));
+    }
+
+    [Test]
+    public async Task JoinHost_WithReconnectTokenFromAnotherNamespace_IssuesNewClientId()
+    {
+        var socket = new TestWebSocket();
+        _registry.Setup(r => r.GetNamespace(socket)).Returns("app-b");
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room9", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = new TestWebSocket();
+                    return true;
+                }
+            );
+        _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-new");
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-new", socket, "room9", out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room9",
+                ReconnectToken = _sessionTokens.Issue(
+                    PeerRole.Client,
+                    "client-old",
+                    "room9",
+                    "app-a"
+                ),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.That(response?.ClientId, Is.EqualTo("client-new"));
+    }
+
+    [Test]
+    public async Task JoinHost_WithMetadata_StoresItAndForwardsToHost()
+    {
+        var socket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room123", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+        _registry.Setup(r => r.GenerateUniqueClientIdAsync()).ReturnsAsync("client-1");
+        IReadOnlyList<string> existingClientIds = [];
+        _registry
+            .Setup(r => r.RegisterClient("client-1", socket, "room123", out existingClientIds))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room123",
+                Metadata = JsonSerializer.SerializeToElement(new { name = "Ada" }),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(
+            r =>
+                r.SetClientMetadata(
+                    socket,
+                    It.Is<JsonElement>(m => m.GetProperty("name").GetString() == "Ada")
+                ),
+            Times.Once
+        );
+
+        var joined = JsonSerializer.Deserialize<SignalMessage>(hostSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(joined?.Type, Is.EqualTo(SignalMessageTypes.ClientJoined));
+            Assert.That(joined?.Metadata?.GetProperty("name").GetString(), Is.EqualTo("Ada"));
+        });
+    }
+
+    [Test]
+    public async Task SetMetadata_BroadcastsPeerUpdatedToRestOfRoom()
+    {
+        var socket = new TestWebSocket();
+        var otherClient = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetClientId(socket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "client-1";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetClientHost(socket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "room123";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetHostSocket("room123", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.GetClientsForHost("room123"))
+            .Returns(new List<WebSocket> { socket, otherClient });
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.SetMetadata,
+                Metadata = JsonSerializer.SerializeToElement(new { name = "Grace" }),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        _registry.Verify(r => r.SetClientMetadata(socket, It.IsAny<JsonElement>()), Times.Once);
+        Assert.That(socket.SentMessages, Is.Empty);
+        foreach (var recipient in new[] { hostSocket, otherClient })
+        {
+            var update = JsonSerializer.Deserialize<SignalMessage>(recipient.SentMessages[0]);
+            Assert.Multiple(() =>
+            {
+                Assert.That(update?.Type, Is.EqualTo(SignalMessageTypes.PeerUpdated));
+                Assert.That(update?.ClientId, Is.EqualTo("client-1"));
+                Assert.That(
+                    update?.Metadata?.GetProperty("name").GetString(),
+                    Is.EqualTo("Grace")
+                );
+            });
+        }
+    }
+
+    [Test]
+    public async Task SetMetadata_UpperCaseTypeWithoutMetadata_IsRejectedAsBadMessage()
+    {
+        var socket = new TestWebSocket();
+        _registry
+            .Setup(r => r.TryGetClientId(socket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "client-1";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetClientHost(socket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "room123";
+                    return true;
+                }
+            );
+
+        await _handler.HandleMessage(socket, """{"type":"SET-METADATA"}""");
+
+        _registry.Verify(
+            r => r.SetClientMetadata(It.IsAny<WebSocket>(), It.IsAny<JsonElement>()),
+            Times.Never
+        );
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.BadMessage));
+    }
+
+    [Test]
+    public async Task JoinHost_WithIdStillConnected_ReplacesPreviousConnection()
+    {
+        var socket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+        var previousSocket = new Mock<WebSocket>();
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room9", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+        WebSocket? replaced = previousSocket.Object;
+        _registry
+            .Setup(r => r.ReplaceClient("client-old", socket, "room9", out replaced))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room9",
+                ReconnectToken = _sessionTokens.Issue(PeerRole.Client, "client-old", "room9"),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        previousSocket.Verify(
+            s =>
+                s.CloseOutputAsync(
+                    WebSocketCloseStatus.PolicyViolation,
+                    "replaced",
+                    It.IsAny<CancellationToken>()
+                ),
+            Times.Once
+        );
+        _registry.Verify(
+            r =>
+                r.RegisterClient(
+                    It.IsAny<string>(),
+                    It.IsAny<WebSocket>(),
+                    It.IsAny<string>(),
+                    out It.Ref<IReadOnlyList<string>>.IsAny!
+                ),
+            Times.Never
+        );
+
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.ClientId, Is.EqualTo("client-old"));
+            Assert.That(hostSocket.SentMessages, Is.Empty);
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_WithStalledPreviousConnection_AbortsItAndAcks()
+    {
+        var socket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+        var previousSocket = new Mock<WebSocket>();
+
+        // Never completes the close, so the handler has to wait out its close timeout
+        previousSocket
+            .Setup(s =>
+                s.CloseOutputAsync(
+                    It.IsAny<WebSocketCloseStatus>(),
+                    It.IsAny<string?>(),
+                    It.IsAny<CancellationToken>()
+                )
+            )
+            .Returns(
+                (WebSocketCloseStatus _, string? _, CancellationToken ct) =>
+                    Task.Delay(Timeout.Infinite, ct)
+            );
+
+        _registry
+            .Setup(r => r.TryGetHostSocket("room9", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+        WebSocket? replaced = previousSocket.Object;
+        _registry
+            .Setup(r => r.ReplaceClient("client-old", socket, "room9", out replaced))
+            .Returns(true);
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.JoinHost,
+                HostId = "room9",
+                ReconnectToken = _sessionTokens.Issue(PeerRole.Client, "client-old", "room9"),
+            }
+        );
+        await _handler.HandleMessage(socket, raw);
+
+        previousSocket.Verify(s => s.Abort(), Times.Once);
+        var response = JsonSerializer.Deserialize<SignalMessage>(socket.SentMessages[0]);
+        Assert.That(response?.ClientId, Is.EqualTo("client-old"));
+    }
+
+    [Test]
+    public async Task HandleBinaryMessage_FromClient_ForwardsPayloadToHostUnchanged()
+    {
+        var clientSocket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetClientId(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "CLIENT";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetClientHost(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "HOST01";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetHostSocket("HOST01", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+
+        byte[] payload = [0x00, 0xFF, 0x10, 0x7B];
+        await _handler.HandleBinaryMessage(clientSocket, BinaryEnvelope.Create("HOST01", payload));
+
+        Assert.That(hostSocket.SentBinaryMessages, Has.Count.EqualTo(1));
+        var parsed = BinaryEnvelope.TryParse(
+            hostSocket.SentBinaryMessages[0],
+            out var senderId,
+            out var forwarded
+        );
+        Assert.Multiple(() =>
+        {
+            Assert.That(parsed, Is.True);
+            Assert.That(senderId, Is.EqualTo("CLIENT"));
+            Assert.That(forwarded.ToArray(), Is.EqualTo(payload));
+        });
+    }
+
+    [Test]
+    public async Task HandleBinaryMessage_DeniedByAuthorizer_IsNotRelayed()
+    {
+        var clientSocket = new TestWebSocket();
+        var hostSocket = new TestWebSocket();
+        SetupClientOfHost(clientSocket, "HOST01");
+        _registry
+            .Setup(r => r.TryGetClientId(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "CLIENT";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetHostSocket("HOST01", out It.Ref<WebSocket>.IsAny!))
+            .Returns(
+                (string _, out WebSocket ws) =>
+                {
+                    ws = hostSocket;
+                    return true;
+                }
+            );
+
+        // Binary frames from a client count as msg-to-host, which the token doesn't grant
+        var authorizer = new Mock<IRoomAuthorizer>();
+        authorizer
+            .Setup(a =>
+                a.CanSend(
+                    clientSocket,
+                    It.Is<SignalMessage>(m => m.Type == SignalMessageTypes.MsgToHost)
+                )
+            )
+            .Returns(false);
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            [
+                new SignalAuthorizationMiddleware(
+                    authorizer.Object,
+                    new Mock<ILogger<SignalAuthorizationMiddleware>>().Object
+                ),
+            ]
+        );
+
+        await handler.HandleBinaryMessage(clientSocket, BinaryEnvelope.Create("HOST01", [1, 2]));
+
+        var error = JsonSerializer.Deserialize<SignalErrorResponse>(clientSocket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(hostSocket.SentBinaryMessages, Is.Empty);
+            Assert.That(error?.Code, Is.EqualTo(SignalErrorCodes.Forbidden));
+        });
+    }
+
+    [Test]
+    public async Task HandleBinaryMessage_FromClientToOtherPeer_ReturnsPeerUnavailable()
+    {
+        var clientSocket = new TestWebSocket();
+
+        _registry
+            .Setup(r => r.TryGetClientId(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "CLIENT";
+                    return true;
+                }
+            );
+        _registry
+            .Setup(r => r.TryGetClientHost(clientSocket, out It.Ref<string>.IsAny!))
+            .Returns(
+                (WebSocket _, out string id) =>
+                {
+                    id = "HOST01";
+                    return true;
+                }
+            );
+
+        await _handler.HandleBinaryMessage(clientSocket, BinaryEnvelope.Create("OTHER1", [1, 2]));
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(
+            clientSocket.SentMessages[0]
+        );
+        Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+    }
+
+    [Test]
+    public async Task HandleBinaryMessage_WithoutHeader_SendsError()
+    {
+        var socket = new TestWebSocket();
+
+        await _handler.HandleBinaryMessage(socket, [0]);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(response?.Code, Is.EqualTo(SignalErrorCodes.BadMessage));
+            Assert.That(response?.Message, Is.EqualTo("Malformed binary frame"));
+        });
+    }
+
+    // ──────────────── TRACING TESTS ────────────────
+
+    [Test]
+    public async Task MsgToClient_RecordsSpanWithTypeAndTarget()
+    {
+        using var collector = new SpanCollector();
+        var hostSocket = new TestWebSocket();
+        SetupHostWithClient(hostSocket, "client1", new TestWebSocket());
+
+        await _handler.HandleMessage(hostSocket, MsgToClientWithId("client1"));
+
+        var span = collector.Spans.Single(s => s.OperationName == "signaling.message");
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                span.GetTagItem(SignalingTracing.MessageTypeAttribute),
+                Is.EqualTo(SignalMessageTypes.MsgToClient)
+            );
+            Assert.That(
+                span.GetTagItem(SignalingTracing.TargetPeerAttribute),
+                Is.EqualTo("client1")
+            );
+        });
+    }
+
+    // ──────────────── MIDDLEWARE TESTS ────────────────
+
+    private class RecordingMiddleware(string name, List<string> calls, bool shortCircuit = false)
+        : ISignalMiddleware
+    {
+        public async Task InvokeAsync(SignalContext context, SignalDelegate next)
+        {
+            calls.Add(name);
+            if (shortCircuit)
+            {
+                await context.Socket.SendErrorAsync(
+                    $"Rejected by {name}",
+                    SignalErrorCodes.Forbidden
+                );
+                return;
+            }
+
+            await next(context);
+        }
+    }
+
+    [Test]
+    public async Task Middleware_RunsInRegistrationOrder_BeforeRouting()
+    {
+        var socket = new TestWebSocket();
+        var calls = new List<string>();
+        _registry.Setup(r => r.GenerateUniqueHostIdAsync()).ReturnsAsync("host-abc");
+        _registry
+            .Setup(r => r.RegisterHost("host-abc", socket, 10, null))
+            .Callback(() => calls.Add("route"))
+            .Returns(true);
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            [new RecordingMiddleware("first", calls), new RecordingMiddleware("second", calls)]
+        );
+
+        var raw = JsonSerializer.Serialize(new SignalMessage { Type = SignalMessageTypes.Host });
+        await handler.HandleMessage(socket, raw);
+
+        Assert.That(calls, Is.EqualTo(new[] { "first", "second", "route" }));
+    }
+
+    [Test]
+    public async Task Middleware_ShortCircuit_RepliesAndSkipsRouting()
+    {
+        var socket = new TestWebSocket();
+        var calls = new List<string>();
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            [
+                new RecordingMiddleware("blocker", calls, shortCircuit: true),
+                new RecordingMiddleware("after", calls),
+            ]
+        );
+
+        var raw = JsonSerializer.Serialize(new SignalMessage { Type = SignalMessageTypes.Host });
+        await handler.HandleMessage(socket, raw);
+
+        var response = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(calls, Is.EqualTo(new[] { "blocker" }));
+            Assert.That(response?.Message, Is.EqualTo("Rejected by blocker"));
+            Assert.That(socket.SentMessages, Has.Count.EqualTo(1));
+        });
+        _registry.Verify(r => r.GenerateUniqueHostIdAsync(), Times.Never);
+        _registry.Verify(
+            r =>
+                r.RegisterHost(
+                    It.IsAny<string>(),
+                    It.IsAny<WebSocket>(),
+                    It.IsAny<int>(),
+                    It.IsAny<string?>()
+                ),
+            Times.Never
+        );
+    }
+
+    [Test]
+    public async Task Middleware_InvalidMessage_IsRejectedBeforeCustomMiddleware()
+    {
+        var socket = new TestWebSocket();
+        var calls = new List<string>();
+        var handler = new MessageHandler(
+            _registry.Object,
+            _sessionTokens,
+            _logger.Object,
+            [new RecordingMiddleware("custom", calls)]
+        );
+
+        var raw = JsonSerializer.Serialize(
+            new SignalMessage { Type = SignalMessageTypes.JoinHost }
+        );
+        await handler.HandleMessage(socket, raw);
+
+        Assert.That(calls, Is.Empty);
+        _logger.VerifyLog(LogLevel.Warning, "Validation failed:", Times.Once());
+    }
 }
@@ -0,0 +1,92 @@
+using Microsoft.AspNetCore.Hosting;
+using Microsoft.AspNetCore.Http;
+using Microsoft.Extensions.Hosting;
+using Moq;
+using SignalingServer.Validation;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class OriginValidatorTests
+{
+    private Mock<IWebHostEnvironment> _environment;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _environment = new Mock<IWebHostEnvironment>();
+        _environment.Setup(e => e.EnvironmentName).Returns(Environments.Production);
+    }
+
+    [Test]
+    public void ConfiguredAllowlist_AllowsListedOrigin()
+    {
+        var validator = new OriginValidator(
+            _environment.Object,
+            "https://app.example.com, https://other.example.com"
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsOriginAllowed("https://app.example.com"), Is.True);
+            Assert.That(validator.IsOriginAllowed("https://other.example.com"), Is.True);
+        });
+    }
+
+    [Test]
+    public void ConfiguredAllowlist_RejectsUnlistedOrigin()
+    {
+        var validator = new OriginValidator(_environment.Object, "https://app.example.com");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsOriginAllowed("https://evil.example.net"), Is.False);
+            Assert.That(validator.IsOriginAllowed("https://squarespheres.com"), Is.False);
+        });
+    }
+
+    [Test]
+    public void ConfiguredAllowlist_SubdomainWildcard_AllowsSubdomains()
+    {
+        var validator = new OriginValidator(_environment.Object, "*.example.com");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsOriginAllowed("https://app.example.com"), Is.True);
+            Assert.That(validator.IsOriginAllowed("https://example.org"), Is.False);
+        });
+    }
+
+    [Test]
+    public void WildcardAllowlist_AllowsAnyOrigin()
+    {
+        var validator = new OriginValidator(_environment.Object, "*");
+
+        Assert.That(validator.IsOriginAllowed("https://anything.example.net"), Is.True);
+    }
+
+    [Test]
+    public void MissingOrigin_IsRejected()
+    {
+        var validator = new OriginValidator(_environment.Object, "*");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsOriginAllowed((string?)null), Is.False);
+            Assert.That(validator.IsOriginAllowed(new DefaultHttpContext()), Is.False);
+        });
+    }
+
+    [Test]
+    public void DefaultAllowlist_AllowsSquareSpheresOrigins()
+    {
+        var validator = new OriginValidator(_environment.Object, "");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsOriginAllowed("https://squarespheres.com"), Is.True);
+            Assert.That(validator.IsOriginAllowed("https://app.squarespheres.com"), Is.True);
+            Assert.That(validator.IsOriginAllowed("http://localhost:3000"), Is.False);
+        });
+    }
+}
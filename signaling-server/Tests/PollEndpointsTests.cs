@@ -0,0 +1,394 @@
+using System.Net.WebSockets;
+using System.Text;
+using System.Text.Json;
+using Microsoft.AspNetCore.Hosting;
+using Microsoft.AspNetCore.Http;
+using Microsoft.AspNetCore.Http.HttpResults;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+using Moq;
+using SignalingServer.Configuration;
+using SignalingServer.Endpoints;
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+using SignalingServer.Validation;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class PollEndpointsTests
+{
+    private static readonly LongPollOptions Options = new(
+        PollTimeout: TimeSpan.FromMilliseconds(200),
+        SessionTimeout: TimeSpan.FromSeconds(60),
+        QueueSize: 16,
+        MaxMessageSize: 1024
+    );
+
+    private FixedTimeProvider _timeProvider;
+    private SignalRegistry _registry;
+    private ConnectionLimiter _connectionLimiter;
+    private LongPollSessions _sessions;
+    private OriginValidator _originValidator;
+    private JwtAuthenticator _authenticator;
+    private RoomIdValidator _roomIdValidator;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _timeProvider = new FixedTimeProvider(DateTimeOffset.UnixEpoch);
+        _registry = new SignalRegistry(new Mock<ILogger<SignalRegistry>>().Object);
+        var sessionTokens = new SessionTokenService(
+            "test-secret"u8.ToArray(),
+            TimeSpan.FromMinutes(5),
+            TimeProvider.System
+        );
+        var messageHandler = new MessageHandler(
+            _registry,
+            sessionTokens,
+            new Mock<ILogger<MessageHandler>>().Object
+        );
+        var connectionHandler = new ConnectionHandler(
+            messageHandler,
+            _registry,
+            new Mock<ILogger<ConnectionHandler>>().Object
+        );
+
+        _connectionLimiter = new ConnectionLimiter(maxConnections: 0);
+        _sessions = new LongPollSessions(
+            connectionHandler,
+            _connectionLimiter,
+            Options,
+            _timeProvider,
+            NullLogger<LongPollSessions>.Instance
+        );
+        _originValidator = new OriginValidator(new Mock<IWebHostEnvironment>().Object, "*");
+        _roomIdValidator = new RoomIdValidator();
+        _authenticator = new JwtAuthenticator(
+            new JwtAuthOptions(null, null, null, null, TimeSpan.FromMinutes(10)),
+            new HttpClient(),
+            TimeProvider.System,
+            NullLogger<JwtAuthenticator>.Instance
+        );
+    }
+
+    [TearDown]
+    public void TearDown()
+    {
+        // Ends every session that is still open
+        _timeProvider.Now += TimeSpan.FromDays(1);
+        _sessions.Sweep();
+        _sessions.Dispose();
+    }
+
+    private static HttpContext CreateContext(string? sessionId = null, string? body = null)
+    {
+        var context = new DefaultHttpContext();
+        context.Request.Headers.Origin = "https://app.example.com";
+        if (sessionId != null)
+            context.Request.Headers[PollEndpoints.SessionHeader] = sessionId;
+        if (body != null)
+            context.Request.Body = new MemoryStream(Encoding.UTF8.GetBytes(body));
+        return context;
+    }
+
+    private async Task<string> OpenSession(string? signalNamespace = null)
+    {
+        var context = CreateContext();
+        if (signalNamespace != null)
+            context.Request.QueryString = QueryString.Create("namespace", signalNamespace);
+
+        var result = await PollEndpoints.OpenSession(
+            context,
+            _sessions,
+            _connectionLimiter,
+            _originValidator,
+            _authenticator,
+            _registry,
+            _roomIdValidator
+        );
+
+        return ((JsonHttpResult<PollSession>)result).Value!.SessionId;
+    }
+
+    private Task<IResult> Send(string sessionId, SignalMessage message) =>
+        PollEndpoints.Send(CreateContext(sessionId, JsonSerializer.Serialize(message)), _sessions);
+
+    private async Task<List<SignalMessage>> Receive(string sessionId)
+    {
+        var result = await PollEndpoints.Receive(CreateContext(sessionId), _sessions);
+        var content = ((ContentHttpResult)result).ResponseContent!;
+        return JsonSerializer.Deserialize<List<SignalMessage>>(content)!;
+    }
+
+    // Polls until a message of the given type arrives, as a client would
+    private async Task<SignalMessage> ReceiveUntil(string sessionId, string type)
+    {
+        for (var i = 0; i < 20; i++)
+        {
+            var match = (await Receive(sessionId)).FirstOrDefault(m => m.Type == type);
+            if (match != null)
+                return match;
+        }
+
+        throw new TimeoutException($"No {type} message for session {sessionId}");
+    }
+
+    [Test]
+    public async Task HostAndClient_JoinAndExchangeMessages_PurelyOverPolling()
+    {
+        var hostSession = await OpenSession();
+        await Send(hostSession, new SignalMessage { Type = SignalMessageTypes.Host });
+        var hostId = (await ReceiveUntil(hostSession, SignalMessageTypes.Host)).HostId!;
+
+        var clientSession = await OpenSession();
+        await Send(
+            clientSession,
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = hostId }
+        );
+        var joined = await ReceiveUntil(clientSession, SignalMessageTypes.JoinHost);
+        var clientJoined = await ReceiveUntil(hostSession, SignalMessageTypes.ClientJoined);
+
+        await Send(
+            clientSession,
+            new SignalMessage { Type = SignalMessageTypes.MsgToHost, Payload = "offer" }
+        );
+        var toHost = await ReceiveUntil(hostSession, SignalMessageTypes.MsgToHost);
+
+        await Send(
+            hostSession,
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.MsgToClient,
+                ClientId = joined.ClientId,
+                Payload = "answer",
+            }
+        );
+        var toClient = await ReceiveUntil(clientSession, SignalMessageTypes.MsgToClient);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(joined.HostId, Is.EqualTo(hostId));
+            Assert.That(clientJoined.ClientId, Is.EqualTo(joined.ClientId));
+            Assert.That(toHost.ClientId, Is.EqualTo(joined.ClientId));
+            Assert.That(toHost.Payload, Is.EqualTo("offer"));
+            Assert.That(toClient.HostId, Is.EqualTo(hostId));
+            Assert.That(toClient.Payload, Is.EqualTo("answer"));
+        });
+    }
+
+    [Test]
+    public async Task JoinHost_OnlyReachesHostsInTheSameNamespace()
+    {
+        var hostSession = await OpenSession("app-a");
+        await Send(hostSession, new SignalMessage { Type = SignalMessageTypes.Host });
+        var hostId = (await ReceiveUntil(hostSession, SignalMessageTypes.Host)).HostId!;
+        var join = new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = hostId };
+
+        var otherAppSession = await OpenSession("app-b");
+        await Send(otherAppSession, join);
+        await ReceiveUntil(otherAppSession, SignalMessageTypes.Error);
+
+        var sameAppSession = await OpenSession("app-a");
+        await Send(sameAppSession, join);
+        var joined = await ReceiveUntil(sameAppSession, SignalMessageTypes.JoinHost);
+
+        var room = _registry.GetRoomSnapshot(hostId);
+        Assert.Multiple(() =>
+        {
+            Assert.That(joined.HostId, Is.EqualTo(hostId));
+            Assert.That(room?.Namespace, Is.EqualTo("app-a"));
+            Assert.That(room?.Clients.Select(c => c.PeerId), Is.EqualTo(new[] { joined.ClientId }));
+        });
+    }
+
+    [Test]
+    public async Task MsgToClient_ToClientInAnotherNamespace_IsNacked()
+    {
+        var otherHostSession = await OpenSession("app-b");
+        await Send(otherHostSession, new SignalMessage { Type = SignalMessageTypes.Host });
+        var otherHostId = (await ReceiveUntil(otherHostSession, SignalMessageTypes.Host)).HostId;
+        var otherClientSession = await OpenSession("app-b");
+        await Send(
+            otherClientSession,
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = otherHostId }
+        );
+        var otherClient = await ReceiveUntil(otherClientSession, SignalMessageTypes.JoinHost);
+
+        var hostSession = await OpenSession("app-a");
+        await Send(hostSession, new SignalMessage { Type = SignalMessageTypes.Host });
+        await ReceiveUntil(hostSession, SignalMessageTypes.Host);
+        await Send(
+            hostSession,
+            new SignalMessage
+            {
+                Type = SignalMessageTypes.MsgToClient,
+                ClientId = otherClient.ClientId,
+                Payload = "offer",
+                RequestId = "req-1",
+            }
+        );
+        var report = await ReceiveUntil(hostSession, SignalMessageTypes.Nack);
+        var otherClientMessages = await Receive(otherClientSession);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(report.RequestId, Is.EqualTo("req-1"));
+            Assert.That(report.Reason, Is.EqualTo(SignalErrorCodes.PeerUnavailable));
+            Assert.That(
+                otherClientMessages.Select(m => m.Type),
+                Has.None.EqualTo(SignalMessageTypes.MsgToClient)
+            );
+        });
+    }
+
+    [Test]
+    public async Task OpenSession_WithInvalidNamespace_Returns400()
+    {
+        var context = CreateContext();
+        context.Request.QueryString = QueryString.Create("namespace", "app a/../b");
+
+        var result = await PollEndpoints.OpenSession(
+            context,
+            _sessions,
+            _connectionLimiter,
+            _originValidator,
+            _authenticator,
+            _registry,
+            _roomIdValidator
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status400BadRequest)
+        );
+    }
+
+    [Test]
+    public async Task OpenSession_WithoutNamespace_IgnoresRoomIdPattern()
+    {
+        // A pattern for generated ids that "default" doesn't match
+        _roomIdValidator = new RoomIdValidator("^[A-Z0-9]{6}$");
+
+        var sessionId = await OpenSession();
+        _sessions.TryGet(sessionId, out var socket);
+
+        Assert.That(_registry.GetNamespace(socket!), Is.EqualTo(SignalNamespaces.Default));
+    }
+
+    [Test]
+    public async Task Receive_WithNothingQueued_ReturnsEmptyAfterPollTimeout()
+    {
+        var session = await OpenSession();
+
+        var messages = await Receive(session);
+
+        Assert.That(messages, Is.Empty);
+    }
+
+    [Test]
+    public async Task CloseSession_TellsHostTheClientDisconnected()
+    {
+        var hostSession = await OpenSession();
+        await Send(hostSession, new SignalMessage { Type = SignalMessageTypes.Host });
+        var hostId = (await ReceiveUntil(hostSession, SignalMessageTypes.Host)).HostId!;
+        var clientSession = await OpenSession();
+        await Send(
+            clientSession,
+            new SignalMessage { Type = SignalMessageTypes.JoinHost, HostId = hostId }
+        );
+        var joined = await ReceiveUntil(clientSession, SignalMessageTypes.JoinHost);
+
+        var result = PollEndpoints.CloseSession(CreateContext(clientSession), _sessions);
+        var left = await ReceiveUntil(hostSession, SignalMessageTypes.ClientDisconnected);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(result, Is.InstanceOf<NoContent>());
+            Assert.That(left.ClientId, Is.EqualTo(joined.ClientId));
+            Assert.That(_sessions.TryGet(clientSession, out _), Is.False);
+        });
+    }
+
+    [Test]
+    public async Task Receive_AfterSessionEnds_Returns410AndForgetsSession()
+    {
+        var session = await OpenSession();
+        _sessions.TryGet(session, out var socket);
+        socket!.Abort();
+
+        var result = await PollEndpoints.Receive(CreateContext(session), _sessions);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(result, Is.InstanceOf<StatusCodeHttpResult>());
+            Assert.That(
+                ((StatusCodeHttpResult)result).StatusCode,
+                Is.EqualTo(StatusCodes.Status410Gone)
+            );
+            Assert.That(_sessions.TryGet(session, out _), Is.False);
+        });
+    }
+
+    [Test]
+    public async Task Send_WithUnknownSession_ReturnsNotFound()
+    {
+        var result = await Send("no-such-session", new SignalMessage { Type = "host" });
+
+        Assert.That(result, Is.InstanceOf<NotFound>());
+    }
+
+    [Test]
+    public async Task Send_WithBodyOverMaxMessageSize_Returns413()
+    {
+        var sessionId = await OpenSession();
+        var context = CreateContext(sessionId, new string('x', Options.MaxMessageSize + 1));
+
+        var result = await PollEndpoints.Send(context, _sessions);
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status413PayloadTooLarge)
+        );
+    }
+
+    [Test]
+    public async Task OpenSession_AtConnectionCeiling_Returns503()
+    {
+        _connectionLimiter = new ConnectionLimiter(maxConnections: 1);
+        await OpenSession();
+
+        var result = await PollEndpoints.OpenSession(
+            CreateContext(),
+            _sessions,
+            _connectionLimiter,
+            _originValidator,
+            _authenticator,
+            _registry,
+            _roomIdValidator
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status503ServiceUnavailable)
+        );
+    }
+
+    [Test]
+    public async Task Sweep_AbortsSessionsThatStoppedPolling()
+    {
+        var session = await OpenSession();
+        _sessions.TryGet(session, out var socket);
+
+        _timeProvider.Now += Options.SessionTimeout + TimeSpan.FromSeconds(1);
+        var removed = _sessions.Sweep();
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(removed, Is.EqualTo(1));
+            Assert.That(socket!.State, Is.EqualTo(WebSocketState.Aborted));
+            Assert.That(_sessions.TryGet(session, out _), Is.False);
+        });
+    }
+}
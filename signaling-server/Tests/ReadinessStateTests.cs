@@ -0,0 +1,54 @@
+using System.Net.WebSockets;
+using Moq;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class ReadinessStateTests
+{
+    private Mock<ISignalRegistry> _registry;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _registry = new Mock<ISignalRegistry>();
+        _registry.Setup(r => r.GetTrackedSockets()).Returns(new List<WebSocket>());
+    }
+
+    [Test]
+    public void IsReady_ByDefault()
+    {
+        var readiness = new ReadinessState(_registry.Object);
+        Assert.That(readiness.IsReady, Is.True);
+    }
+
+    [Test]
+    public void IsReady_False_AfterShutdownStarts()
+    {
+        var readiness = new ReadinessState(_registry.Object);
+
+        readiness.MarkShuttingDown();
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(readiness.IsShuttingDown, Is.True);
+            Assert.That(readiness.IsReady, Is.False);
+        });
+    }
+
+    [Test]
+    public void IsReady_False_WhenConnectionLimitReached()
+    {
+        _registry
+            .Setup(r => r.GetTrackedSockets())
+            .Returns(new List<WebSocket> { new TestWebSocket(), new TestWebSocket() });
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(new ReadinessState(_registry.Object, maxConnections: 2).IsReady, Is.False);
+            Assert.That(new ReadinessState(_registry.Object, maxConnections: 3).IsReady, Is.True);
+        });
+    }
+}
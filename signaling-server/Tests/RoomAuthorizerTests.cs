@@ -0,0 +1,163 @@
+using System.Net.WebSockets;
+using System.Security.Claims;
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using Moq;
+using SignalingServer.Endpoints;
+using SignalingServer.Middleware;
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class RoomAuthorizerTests
+{
+    private class ClosableWebSocket : TestWebSocket
+    {
+        public bool IsClosed { get; private set; }
+
+        public override Task CloseOutputAsync(
+            WebSocketCloseStatus closeStatus,
+            string? statusDescription,
+            CancellationToken cancellationToken
+        )
+        {
+            IsClosed = true;
+            return Task.CompletedTask;
+        }
+    }
+
+    private SignalRegistry _registry;
+    private TestWebSocket _socket;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _registry = new SignalRegistry(new Mock<ILogger<SignalRegistry>>().Object);
+        _socket = new TestWebSocket();
+        _registry.TrackSocket(_socket);
+    }
+
+    [TearDown]
+    public void TearDown()
+    {
+        _registry.UntrackSocket(_socket);
+    }
+
+    private static SignalMessage Message(string type) => new() { Type = type, RequestId = "req-1" };
+
+    [Test]
+    public void AllowAll_AllowsJoiningAndSending()
+    {
+        var authorizer = new AllowAllAuthorizer();
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(authorizer.CanJoin(_socket, "HOST01"), Is.True);
+            Assert.That(
+                authorizer.CanSend(_socket, Message(SignalMessageTypes.MsgToHost)),
+                Is.True
+            );
+        });
+    }
+
+    [Test]
+    public void TokenClaims_CanJoin_AllowsOnlyRoomsInToken()
+    {
+        var authorizer = new TokenClaimsAuthorizer(_registry);
+        _registry.SetAllowedHosts(_socket, new HashSet<string> { "HOST01" });
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(authorizer.CanJoin(_socket, "HOST01"), Is.True);
+            Assert.That(authorizer.CanJoin(_socket, "HOST02"), Is.False);
+        });
+    }
+
+    [Test]
+    public void TokenClaims_CanSend_AllowsOnlyActionsInToken()
+    {
+        var authorizer = new TokenClaimsAuthorizer(_registry);
+        _registry.SetAllowedActions(_socket, new HashSet<string> { SignalMessageTypes.JoinHost });
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(authorizer.CanSend(_socket, Message(SignalMessageTypes.JoinHost)), Is.True);
+            Assert.That(
+                authorizer.CanSend(_socket, Message(SignalMessageTypes.MsgToHost)),
+                Is.False
+            );
+        });
+    }
+
+    [Test]
+    public void TokenClaims_WithoutRestrictions_AllowsEverything()
+    {
+        var authorizer = new TokenClaimsAuthorizer(_registry);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(authorizer.CanJoin(_socket, "HOST01"), Is.True);
+            Assert.That(
+                authorizer.CanSend(_socket, Message(SignalMessageTypes.MsgToHost)),
+                Is.True
+            );
+        });
+    }
+
+    [Test]
+    public void TokenClaims_TokenWithoutRoomsClaim_CanJoinAnyHost()
+    {
+        var authorizer = new TokenClaimsAuthorizer(_registry);
+        var identity = new ClaimsIdentity(
+            [new Claim(JwtAuthenticator.ActionsClaim, SignalMessageTypes.JoinHost)]
+        );
+
+        WebSocketEndpoints.ApplyTokenClaims(_registry, _socket, identity);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(authorizer.CanJoin(_socket, "HOST01"), Is.True);
+            Assert.That(
+                authorizer.CanSend(_socket, Message(SignalMessageTypes.MsgToHost)),
+                Is.False
+            );
+        });
+    }
+
+    [Test]
+    public async Task AuthorizationMiddleware_DeniedSend_RepliesWithErrorAndKeepsConnection()
+    {
+        var authorizer = new Mock<IRoomAuthorizer>();
+        authorizer
+            .Setup(a => a.CanSend(It.IsAny<WebSocket>(), It.IsAny<SignalMessage>()))
+            .Returns(false);
+        var socket = new ClosableWebSocket();
+        var middleware = new SignalAuthorizationMiddleware(
+            authorizer.Object,
+            new Mock<ILogger<SignalAuthorizationMiddleware>>().Object
+        );
+        var reachedNext = false;
+
+        var message = Message(SignalMessageTypes.MsgToHost);
+        await middleware.InvokeAsync(
+            new SignalContext(socket, message, JsonSerializer.Serialize(message)),
+            _ =>
+            {
+                reachedNext = true;
+                return Task.CompletedTask;
+            }
+        );
+
+        var error = JsonSerializer.Deserialize<SignalErrorResponse>(socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(reachedNext, Is.False);
+            Assert.That(error?.Code, Is.EqualTo(SignalErrorCodes.Forbidden));
+            Assert.That(error?.RequestId, Is.EqualTo("req-1"));
+            Assert.That(socket.IsClosed, Is.False);
+        });
+    }
+}
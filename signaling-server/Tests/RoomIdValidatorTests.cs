@@ -0,0 +1,53 @@
+using SignalingServer.Validation;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class RoomIdValidatorTests
+{
+    [TestCase("ABC123")]
+    [TestCase("my-room_2")]
+    public void IsValid_AcceptsIdsMatchingTheDefaultPattern(string roomId)
+    {
+        var validator = new RoomIdValidator(RoomIdValidator.DefaultPattern);
+
+        Assert.That(validator.IsValid(roomId), Is.True);
+    }
+
+    [Test]
+    public void IsValid_RejectsOverlongIds()
+    {
+        var validator = new RoomIdValidator(RoomIdValidator.DefaultPattern);
+
+        Assert.That(validator.IsValid(new string('a', 65)), Is.False);
+    }
+
+    [TestCase("room 1")]
+    [TestCase("room\nINFO forged log line")]
+    [TestCase("rooms:*")]
+    [TestCase("")]
+    public void IsValid_RejectsDisallowedCharacters(string roomId)
+    {
+        var validator = new RoomIdValidator(RoomIdValidator.DefaultPattern);
+
+        Assert.That(validator.IsValid(roomId), Is.False);
+    }
+
+    [Test]
+    public void IsValid_UsesConfiguredPattern()
+    {
+        var validator = new RoomIdValidator("^[0-9]{4}$");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(validator.IsValid("1234"), Is.True);
+            Assert.That(validator.IsValid("ABCD"), Is.False);
+        });
+    }
+
+    [Test]
+    public void Constructor_WithInvalidPattern_Throws()
+    {
+        Assert.Throws<ArgumentException>(() => new RoomIdValidator("[unclosed"));
+    }
+}
@@ -0,0 +1,90 @@
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class SessionTokenServiceTests
+{
+    private FixedTimeProvider _time;
+    private SessionTokenService _service;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _time = new FixedTimeProvider(DateTimeOffset.FromUnixTimeSeconds(1700000000));
+        _service = new SessionTokenService(
+            "test-secret"u8.ToArray(),
+            TimeSpan.FromMinutes(5),
+            _time
+        );
+    }
+
+    [Test]
+    public void TryValidate_TokenWithinWindow_ReturnsIdentity()
+    {
+        var token = _service.Issue(PeerRole.Client, "CLIENT1", "HOST01");
+
+        _time.Now = _time.Now.AddMinutes(4);
+
+        Assert.That(_service.TryValidate(token, out var session), Is.True);
+        Assert.Multiple(() =>
+        {
+            Assert.That(session!.Role, Is.EqualTo(PeerRole.Client));
+            Assert.That(session.PeerId, Is.EqualTo("CLIENT1"));
+            Assert.That(session.HostId, Is.EqualTo("HOST01"));
+            Assert.That(session.Namespace, Is.EqualTo(SignalNamespaces.Default));
+        });
+    }
+
+    [Test]
+    public void TryValidate_TokenIssuedInNamespace_CarriesIt()
+    {
+        var token = _service.Issue(PeerRole.Host, "HOST01", "HOST01", "app-a");
+
+        Assert.That(_service.TryValidate(token, out var session), Is.True);
+        Assert.That(session!.Namespace, Is.EqualTo("app-a"));
+    }
+
+    [Test]
+    public void TryValidate_ExpiredToken_IsRejected()
+    {
+        var token = _service.Issue(PeerRole.Host, "HOST01", "HOST01");
+
+        _time.Now = _time.Now.AddMinutes(6);
+
+        Assert.That(_service.TryValidate(token, out _), Is.False);
+    }
+
+    [Test]
+    public void TryValidate_TamperedToken_IsRejected()
+    {
+        var token = _service.Issue(PeerRole.Client, "CLIENT1", "HOST01");
+        var forged = new SessionTokenService(
+            "other-secret"u8.ToArray(),
+            TimeSpan.FromMinutes(5),
+            _time
+        ).Issue(PeerRole.Client, "CLIENT2", "HOST01");
+
+        // Combine the forged payload with the genuine signature
+        var tampered = $"{forged.Split('.')[0]}.{token.Split('.')[1]}";
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_service.TryValidate(forged, out _), Is.False);
+            Assert.That(_service.TryValidate(tampered, out _), Is.False);
+        });
+    }
+
+    [Test]
+    public void TryValidate_MalformedToken_IsRejected()
+    {
+        Assert.Multiple(() =>
+        {
+            Assert.That(_service.TryValidate(null, out _), Is.False);
+            Assert.That(_service.TryValidate("not-a-token", out _), Is.False);
+            Assert.That(_service.TryValidate("!!.??", out _), Is.False);
+        });
+    }
+}
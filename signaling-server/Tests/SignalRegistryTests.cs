@@ -1,6 +1,8 @@
 using System.Net.WebSockets;
+using System.Text.Json;
 using Microsoft.Extensions.Logging;
 using Moq;
+using SignalingServer.Models;
 using SignalingServer.Services;
 using SignalingServer.Tests.Helpers;
 
@@ -149,6 +151,110 @@ public class SignalRegistryTests
         });
     }
 
+    [Test]
+    public void RegisterClient_ReturnsSnapshotOfExistingClients()
+    {
+        var hostId = "HOST01";
+        _registry.RegisterHost(hostId, CreateSocket());
+        _registry.RegisterClient("c1", CreateSocket(), hostId);
+        _registry.RegisterClient("c2", CreateSocket(), hostId);
+        _registry.RegisterClient("other", CreateSocket(), "HOST02");
+
+        var registered = _registry.RegisterClient(
+            "c3",
+            CreateSocket(),
+            hostId,
+            out var existingClientIds
+        );
+
+        Assert.That(registered, Is.True);
+        Assert.That(existingClientIds, Is.EquivalentTo(new[] { "c1", "c2" }));
+    }
+
+    [Test]
+    public void ReplaceClient_MovesIdToNewSocket_KeepingSingleMember()
+    {
+        var hostId = "HOST01";
+        var firstSocket = CreateSocket();
+        var secondSocket = CreateSocket();
+        _registry.RegisterHost(hostId, CreateSocket(), maxClients: 1);
+        _registry.RegisterClient("X", firstSocket, hostId);
+
+        var replaced = _registry.ReplaceClient("X", secondSocket, hostId, out var previous);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(replaced, Is.True);
+            Assert.That(previous, Is.SameAs(firstSocket));
+            Assert.That(_registry.GetClientsForHost(hostId), Is.EqualTo(new[] { secondSocket }));
+            Assert.That(_registry.TryGetClientId(firstSocket, out _), Is.False);
+            Assert.That(_registry.TryGetClientSocket("X", out var current), Is.True);
+            Assert.That(current, Is.SameAs(secondSocket));
+        });
+
+        // The member count is unchanged, so the full host still has no free slot
+        Assert.That(_registry.RegisterClient("Y", CreateSocket(), hostId), Is.False);
+    }
+
+    [Test]
+    public void ReplaceClient_IdOfAnotherHost_ReturnsFalse()
+    {
+        _registry.RegisterClient("X", CreateSocket(), "HOST01");
+
+        Assert.That(_registry.ReplaceClient("X", CreateSocket(), "HOST02", out _), Is.False);
+    }
+
+    [Test]
+    public async Task RegisterClient_And_RemoveClient_Concurrently_LeavesNoMembers()
+    {
+        const string hostId = "hostRace";
+        _registry.RegisterHost(hostId, CreateSocket(), 1000);
+
+        var tasks = Enumerable
+            .Range(0, 200)
+            .Select(i =>
+                Task.Run(() =>
+                {
+                    var socket = CreateSocket();
+                    _registry.RegisterClient($"client{i}", socket, hostId);
+                    _registry.RemoveClient(socket);
+                })
+            );
+
+        await Task.WhenAll(tasks);
+
+        Assert.That(_registry.GetClientsForHost(hostId), Is.Empty);
+    }
+
+    [Test]
+    public async Task RegisterClient_ConcurrentJoinsPastCapacity_RejectsExactlyOne()
+    {
+        const string hostId = "hostCap";
+        const int capacity = 50;
+        _registry.RegisterHost(hostId, CreateSocket(), capacity);
+
+        using var start = new ManualResetEventSlim(false);
+        var tasks = Enumerable
+            .Range(0, capacity + 1)
+            .Select(i =>
+                Task.Run(() =>
+                {
+                    start.Wait();
+                    return _registry.RegisterClient($"client{i}", CreateSocket(), hostId);
+                })
+            )
+            .ToList();
+
+        start.Set();
+        var results = await Task.WhenAll(tasks);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(results.Count(added => !added), Is.EqualTo(1));
+            Assert.That(_registry.GetClientsForHost(hostId).Count(), Is.EqualTo(capacity));
+        });
+    }
+
     [Test]
     public void RemoveHost_ById_RemovesHost()
     {
@@ -177,6 +283,34 @@ public class SignalRegistryTests
         });
     }
 
+    [Test]
+    public void RegisterHost_And_RemoveHost_UpdateActiveRoomsGauge()
+    {
+        var before = SignalingMetrics.ActiveRooms.Value;
+
+        var socket = CreateSocket();
+        _registry.RegisterHost("hostMetrics", socket, 10);
+        Assert.That(SignalingMetrics.ActiveRooms.Value, Is.EqualTo(before + 1));
+
+        _registry.RemoveHost(socket);
+        _registry.RemoveHost("hostMetrics");
+        Assert.That(SignalingMetrics.ActiveRooms.Value, Is.EqualTo(before));
+    }
+
+    [Test]
+    public void Track_Untrack_Socket_UpdatesActiveConnectionsGauge()
+    {
+        var before = SignalingMetrics.ActiveConnections.Value;
+
+        var socket = CreateSocket();
+        _registry.TrackSocket(socket);
+        Assert.That(SignalingMetrics.ActiveConnections.Value, Is.EqualTo(before + 1));
+
+        _registry.UntrackSocket(socket);
+        _registry.UntrackSocket(socket);
+        Assert.That(SignalingMetrics.ActiveConnections.Value, Is.EqualTo(before));
+    }
+
     [Test]
     public void Track_Untrack_Socket_BehavesCorrectly()
     {
@@ -184,4 +318,134 @@ public class SignalRegistryTests
         _registry.TrackSocket(socket);
         _registry.UntrackSocket(socket);
     }
+
+    [Test]
+    public void IsHostAllowed_WithoutRestriction_AllowsAnyHost()
+    {
+        var socket = CreateSocket();
+        Assert.That(_registry.IsHostAllowed(socket, "HOST01"), Is.True);
+    }
+
+    [Test]
+    public void IsHostAllowed_WithRestriction_AllowsOnlyListedHosts()
+    {
+        var socket = CreateSocket();
+        _registry.TrackSocket(socket);
+        _registry.SetAllowedHosts(socket, new HashSet<string> { "HOST01" });
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_registry.IsHostAllowed(socket, "HOST01"), Is.True);
+            Assert.That(_registry.IsHostAllowed(socket, "HOST02"), Is.False);
+        });
+
+        _registry.UntrackSocket(socket);
+        Assert.That(_registry.IsHostAllowed(socket, "HOST02"), Is.True);
+    }
+
+    [Test]
+    public void IsActionAllowed_WithRestriction_AllowsOnlyListedTypes()
+    {
+        var socket = CreateSocket();
+        _registry.TrackSocket(socket);
+
+        Assert.That(_registry.IsActionAllowed(socket, SignalMessageTypes.MsgToHost), Is.True);
+
+        _registry.SetAllowedActions(socket, new HashSet<string> { SignalMessageTypes.JoinHost });
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_registry.IsActionAllowed(socket, SignalMessageTypes.JoinHost), Is.True);
+            Assert.That(_registry.IsActionAllowed(socket, SignalMessageTypes.MsgToHost), Is.False);
+        });
+
+        _registry.UntrackSocket(socket);
+        Assert.That(_registry.IsActionAllowed(socket, SignalMessageTypes.MsgToHost), Is.True);
+    }
+
+    [Test]
+    public void IsJoinSecretValid_WithoutSecret_AdmitsAnyone()
+    {
+        _registry.RegisterHost("HOST01", CreateSocket(), 10);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_registry.IsJoinSecretValid("HOST01", null), Is.True);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", "anything"), Is.True);
+        });
+    }
+
+    [Test]
+    public void IsJoinSecretValid_WithSecret_AdmitsOnlyMatchingSecret()
+    {
+        _registry.RegisterHost("HOST01", CreateSocket(), 10, "hunter2");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_registry.IsJoinSecretValid("HOST01", "hunter2"), Is.True);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", "hunter3"), Is.False);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", ""), Is.False);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", null), Is.False);
+        });
+    }
+
+    [Test]
+    public void RegisterHost_WithTakenId_KeepsExistingSecret()
+    {
+        _registry.RegisterHost("HOST01", CreateSocket(), 10, "hunter2");
+
+        var registered = _registry.RegisterHost("HOST01", CreateSocket(), 10, "other");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(registered, Is.False);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", "hunter2"), Is.True);
+            Assert.That(_registry.IsJoinSecretValid("HOST01", "other"), Is.False);
+        });
+    }
+
+    [Test]
+    public void RemoveHost_ClearsJoinSecret()
+    {
+        var socket = CreateSocket();
+        _registry.RegisterHost("HOST01", socket, 10, "hunter2");
+
+        _registry.RemoveHost(socket);
+        _registry.RegisterHost("HOST01", CreateSocket(), 10);
+
+        Assert.That(_registry.IsJoinSecretValid("HOST01", null), Is.True);
+    }
+
+    [Test]
+    public void GetNamespace_IsDefaultUntilSetAndAfterUntrack()
+    {
+        var socket = CreateSocket();
+        _registry.TrackSocket(socket);
+        var before = _registry.GetNamespace(socket);
+        _registry.SetNamespace(socket, "app-a");
+        var during = _registry.GetNamespace(socket);
+        _registry.UntrackSocket(socket);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(before, Is.EqualTo(SignalNamespaces.Default));
+            Assert.That(during, Is.EqualTo("app-a"));
+            Assert.That(_registry.GetNamespace(socket), Is.EqualTo(SignalNamespaces.Default));
+        });
+    }
+
+    [Test]
+    public void ClientMetadata_IsStoredUntilClientIsRemoved()
+    {
+        var socket = CreateSocket();
+        _registry.RegisterClient("client1", socket, "host1");
+        var metadata = JsonSerializer.SerializeToElement(new { name = "Ada" });
+        _registry.SetClientMetadata(socket, metadata);
+
+        Assert.That(_registry.TryGetClientMetadata("client1", out var stored), Is.True);
+        Assert.That(stored.GetProperty("name").GetString(), Is.EqualTo("Ada"));
+
+        _registry.RemoveClient(socket);
+        Assert.That(_registry.TryGetClientMetadata("client1", out _), Is.False);
+    }
 }
@@ -0,0 +1,54 @@
+using SignalingServer.Configuration;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class SignalSubprotocolsTests
+{
+    private static readonly string[] Supported = [SignalSubprotocols.V1];
+
+    [Test]
+    public void TryNegotiate_SupportedProtocol_SelectsIt()
+    {
+        var negotiated = SignalSubprotocols.TryNegotiate(
+            ["signal.v2", "signal.v1"],
+            Supported,
+            out var selected
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(negotiated, Is.True);
+            Assert.That(selected, Is.EqualTo(SignalSubprotocols.V1));
+        });
+    }
+
+    [Test]
+    public void TryNegotiate_NoProtocol_AcceptsWithDefaultVersion()
+    {
+        var negotiated = SignalSubprotocols.TryNegotiate([], Supported, out var selected);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(negotiated, Is.True);
+            Assert.That(selected, Is.Null);
+            Assert.That(
+                SignalSubprotocols.GetProtocol(new TestWebSocket()),
+                Is.EqualTo(SignalSubprotocols.V1)
+            );
+        });
+    }
+
+    [Test]
+    public void TryNegotiate_UnsupportedProtocol_IsRejected()
+    {
+        var negotiated = SignalSubprotocols.TryNegotiate(["chat"], Supported, out var selected);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(negotiated, Is.False);
+            Assert.That(selected, Is.Null);
+        });
+    }
+}
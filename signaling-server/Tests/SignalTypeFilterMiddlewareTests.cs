@@ -0,0 +1,77 @@
+using System.Text.Json;
+using Microsoft.Extensions.Logging;
+using Moq;
+using SignalingServer.Middleware;
+using SignalingServer.Models;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class SignalTypeFilterMiddlewareTests
+{
+    private Mock<ILogger<SignalTypeFilterMiddleware>> _logger;
+    private TestWebSocket _socket;
+    private bool _reachedNext;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _logger = new Mock<ILogger<SignalTypeFilterMiddleware>>();
+        _socket = new TestWebSocket();
+        _reachedNext = false;
+    }
+
+    private Task InvokeAsync(SignalTypeFilterMiddleware middleware, string type)
+    {
+        var message = new SignalMessage { Type = type, RequestId = "req-1" };
+        return middleware.InvokeAsync(
+            new SignalContext(_socket, message, JsonSerializer.Serialize(message)),
+            _ =>
+            {
+                _reachedNext = true;
+                return Task.CompletedTask;
+            }
+        );
+    }
+
+    [Test]
+    public async Task ConfiguredTypes_RejectsOtherTypesBeforeRouting()
+    {
+        var middleware = new SignalTypeFilterMiddleware(_logger.Object, "host, join-host");
+
+        await InvokeAsync(middleware, SignalMessageTypes.MsgToHost);
+
+        var error = JsonSerializer.Deserialize<SignalErrorResponse>(_socket.SentMessages[0]);
+        Assert.Multiple(() =>
+        {
+            Assert.That(_reachedNext, Is.False);
+            Assert.That(error?.Code, Is.EqualTo(SignalErrorCodes.TypeNotAllowed));
+            Assert.That(error?.RequestId, Is.EqualTo("req-1"));
+        });
+    }
+
+    [Test]
+    public async Task ConfiguredTypes_PassesListedTypeIgnoringCase()
+    {
+        var middleware = new SignalTypeFilterMiddleware(_logger.Object, "host, join-host");
+
+        await InvokeAsync(middleware, "JOIN-HOST");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(_reachedNext, Is.True);
+            Assert.That(_socket.SentMessages, Is.Empty);
+        });
+    }
+
+    [Test]
+    public async Task NoConfiguredTypes_PassesEveryType()
+    {
+        var middleware = new SignalTypeFilterMiddleware(_logger.Object);
+
+        await InvokeAsync(middleware, SignalMessageTypes.MsgToClient);
+
+        Assert.That(_reachedNext, Is.True);
+    }
+}
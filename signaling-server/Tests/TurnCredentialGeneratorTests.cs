@@ -0,0 +1,58 @@
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class TurnCredentialGeneratorTests
+{
+    private const string Secret = "coturn-shared-secret";
+
+    [Test]
+    public void ComputePassword_MatchesCoturnHmac()
+    {
+        // Reference value: base64(HMAC-SHA1(secret, username)) as computed by coturn
+        var password = TurnCredentialGenerator.ComputePassword(Secret, "1700000000:peer1");
+
+        Assert.That(password, Is.EqualTo("0ejtqcgbxs6cU+M5KFkypaNXkeA="));
+    }
+
+    [Test]
+    public void Generate_UsesExpiryTimestampAndPeerIdAsUsername()
+    {
+        var now = DateTimeOffset.FromUnixTimeSeconds(1700000000 - 3600);
+        var generator = new TurnCredentialGenerator(
+            Secret,
+            ["turn:turn.example.com:3478"],
+            TimeSpan.FromHours(1),
+            new FixedTimeProvider(now)
+        );
+
+        var credentials = generator.Generate("peer1");
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(credentials.Username, Is.EqualTo("1700000000:peer1"));
+            Assert.That(credentials.Password, Is.EqualTo("0ejtqcgbxs6cU+M5KFkypaNXkeA="));
+            Assert.That(credentials.Ttl, Is.EqualTo(3600));
+            Assert.That(credentials.Urls, Is.EqualTo(new[] { "turn:turn.example.com:3478" }));
+        });
+    }
+
+    [Test]
+    public void Generate_WithoutSecret_Throws()
+    {
+        var generator = new TurnCredentialGenerator(
+            null,
+            [],
+            TimeSpan.FromHours(12),
+            TimeProvider.System
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(generator.IsConfigured, Is.False);
+            Assert.Throws<InvalidOperationException>(() => generator.Generate("peer1"));
+        });
+    }
+}
@@ -0,0 +1,103 @@
+using System.Text;
+using Microsoft.AspNetCore.Http;
+using Microsoft.AspNetCore.Http.HttpResults;
+using Microsoft.Extensions.Logging.Abstractions;
+using Microsoft.IdentityModel.JsonWebTokens;
+using Microsoft.IdentityModel.Tokens;
+using SignalingServer.Configuration;
+using SignalingServer.Endpoints;
+using SignalingServer.Models;
+using SignalingServer.Services;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class TurnEndpointsTests
+{
+    private const string HmacSecret = "test-secret-that-is-at-least-32-bytes-long";
+
+    private TurnCredentialGenerator _generator;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _generator = new TurnCredentialGenerator(
+            "coturn-shared-secret",
+            ["turn:turn.example.com:3478"],
+            TimeSpan.FromHours(1),
+            TimeProvider.System
+        );
+    }
+
+    private static JwtAuthenticator CreateAuthenticator(string? hmacSecret) =>
+        new(
+            new JwtAuthOptions(hmacSecret, null, null, null, TimeSpan.FromMinutes(10)),
+            new HttpClient(),
+            TimeProvider.System,
+            NullLogger<JwtAuthenticator>.Instance
+        );
+
+    private static HttpContext CreateContext(string? bearerToken = null)
+    {
+        var context = new DefaultHttpContext();
+        if (bearerToken != null)
+            context.Request.Headers.Authorization = "Bearer " + bearerToken;
+        return context;
+    }
+
+    private static string CreateToken()
+    {
+        return new JsonWebTokenHandler().CreateToken(
+            new SecurityTokenDescriptor
+            {
+                Expires = DateTime.UtcNow.AddHours(1),
+                SigningCredentials = new SigningCredentials(
+                    new SymmetricSecurityKey(Encoding.UTF8.GetBytes(HmacSecret)),
+                    SecurityAlgorithms.HmacSha256
+                ),
+            }
+        );
+    }
+
+    [Test]
+    public async Task GetTurnCredentials_WithoutAuth_IssuesCredentials()
+    {
+        var result = await TurnEndpoints.GetTurnCredentials(
+            CreateContext(),
+            "peer1",
+            _generator,
+            CreateAuthenticator(hmacSecret: null)
+        );
+
+        Assert.That(result, Is.InstanceOf<JsonHttpResult<TurnCredentials>>());
+    }
+
+    [Test]
+    public async Task GetTurnCredentials_AuthEnabledWithoutToken_Returns401()
+    {
+        var result = await TurnEndpoints.GetTurnCredentials(
+            CreateContext(),
+            "peer1",
+            _generator,
+            CreateAuthenticator(HmacSecret)
+        );
+
+        Assert.That(
+            ((IStatusCodeHttpResult)result).StatusCode,
+            Is.EqualTo(StatusCodes.Status401Unauthorized)
+        );
+    }
+
+    [Test]
+    public async Task GetTurnCredentials_AuthEnabledWithValidToken_IssuesCredentials()
+    {
+        var result = await TurnEndpoints.GetTurnCredentials(
+            CreateContext(CreateToken()),
+            "peer1",
+            _generator,
+            CreateAuthenticator(HmacSecret)
+        );
+
+        Assert.That(result, Is.InstanceOf<JsonHttpResult<TurnCredentials>>());
+    }
+}
@@ -0,0 +1,121 @@
+using System.Net.WebSockets;
+using System.Text;
+using Microsoft.AspNetCore.Builder;
+using Microsoft.AspNetCore.Hosting;
+using Microsoft.AspNetCore.Hosting.Server;
+using Microsoft.AspNetCore.Hosting.Server.Features;
+using Microsoft.AspNetCore.Http;
+using Microsoft.Extensions.DependencyInjection;
+using SignalingServer.Endpoints;
+using SignalingServer.Extensions;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class WebSocketCompressionTests
+{
+    private const int MaxMessageSize = 1024 * 1024;
+
+    private WebApplication _app;
+    private Uri _echoUri;
+
+    /// <summary>
+    /// Starts a loopback server that accepts upgrades the way the /ws endpoint does and echoes
+    /// every message back until the client closes.
+    /// </summary>
+    private async Task StartEchoServer(bool enableCompression)
+    {
+        var builder = WebApplication.CreateBuilder();
+        builder.WebHost.UseUrls("http://127.0.0.1:0");
+        _app = builder.Build();
+        _app.UseWebSockets();
+        _app.Map(
+            "/ws",
+            async (HttpContext context) =>
+            {
+                var acceptContext = WebSocketEndpoints.CreateAcceptContext(
+                    new WebSocketOptions(),
+                    subProtocol: null,
+                    enableCompression
+                );
+                using var socket = await context.WebSockets.AcceptWebSocketAsync(acceptContext);
+                while (await socket.ReceiveFullMessageAsync(MaxMessageSize) is { } message)
+                {
+                    await socket.SendRawAsync(message);
+                }
+                await socket.CloseAsync(WebSocketCloseStatus.NormalClosure, null, default);
+            }
+        );
+        await _app.StartAsync();
+
+        var address = _app
+            .Services.GetRequiredService<IServer>()
+            .Features.Get<IServerAddressesFeature>()!
+            .Addresses.First();
+        _echoUri = new Uri(address.Replace("http://", "ws://") + "/ws");
+    }
+
+    [TearDown]
+    public async Task TearDown()
+    {
+        await _app.DisposeAsync();
+    }
+
+    private static ClientWebSocket CreateDeflateClient()
+    {
+        var client = new ClientWebSocket();
+        client.Options.CollectHttpResponseDetails = true;
+        client.Options.DangerousDeflateOptions = new WebSocketDeflateOptions();
+        return client;
+    }
+
+    // An SDP offer with enough candidates to be worth compressing
+    private static string CreateLargeOffer()
+    {
+        var sdp = new StringBuilder("v=0\\r\\no=- 46117317 2 IN IP4 127.0.0.1\\r\\n");
+        for (var i = 0; i < 500; i++)
+        {
+            sdp.Append($"a=candidate:{i} 1 udp 2122260223 192.0.2.{i % 255} {50000 + i}");
+            sdp.Append(" typ host\\r\\n");
+        }
+
+        return $"{{\"type\":\"msg-to-host\",\"payload\":\"{sdp}\"}}";
+    }
+
+    [Test]
+    public async Task CompressionEnabled_NegotiatesDeflateAndRoundTripsLargeOffer()
+    {
+        await StartEchoServer(enableCompression: true);
+        using var client = CreateDeflateClient();
+        var offer = CreateLargeOffer();
+
+        await client.ConnectAsync(_echoUri, CancellationToken.None);
+        await client.SendRawAsync(offer);
+        var echoed = await client.ReceiveFullMessageAsync(MaxMessageSize);
+        await client.CloseAsync(WebSocketCloseStatus.NormalClosure, null, CancellationToken.None);
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                client.HttpResponseHeaders?["Sec-WebSocket-Extensions"].Single(),
+                Does.StartWith("permessage-deflate")
+            );
+            Assert.That(echoed, Is.EqualTo(offer));
+        });
+    }
+
+    [Test]
+    public async Task CompressionDisabled_DoesNotAdvertiseDeflate()
+    {
+        await StartEchoServer(enableCompression: false);
+        using var client = CreateDeflateClient();
+
+        await client.ConnectAsync(_echoUri, CancellationToken.None);
+        await client.CloseAsync(WebSocketCloseStatus.NormalClosure, null, CancellationToken.None);
+
+        Assert.That(
+            client.HttpResponseHeaders?.ContainsKey("Sec-WebSocket-Extensions"),
+            Is.False
+        );
+    }
+}
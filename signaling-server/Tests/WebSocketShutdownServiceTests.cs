@@ -0,0 +1,141 @@
+using System.Net.WebSockets;
+using Microsoft.AspNetCore.Builder;
+using Microsoft.AspNetCore.Hosting;
+using Microsoft.AspNetCore.Hosting.Server;
+using Microsoft.AspNetCore.Hosting.Server.Features;
+using Microsoft.AspNetCore.Http;
+using Microsoft.Extensions.DependencyInjection;
+using Microsoft.Extensions.Hosting;
+using Microsoft.Extensions.Hosting.Internal;
+using Microsoft.Extensions.Logging;
+using Microsoft.Extensions.Logging.Abstractions;
+using Moq;
+using SignalingServer.Extensions;
+using SignalingServer.Services;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class WebSocketShutdownServiceTests
+{
+    private Mock<ISignalRegistry> _registry;
+    private Mock<ILogger<WebSocketShutdownService>> _logger;
+    private ReadinessState _readiness;
+    private ApplicationLifetime _lifetime;
+    private WebSocketShutdownService _service;
+    private WebApplication? _app;
+
+    [SetUp]
+    public void SetUp()
+    {
+        _registry = new Mock<ISignalRegistry>();
+        _logger = new Mock<ILogger<WebSocketShutdownService>>();
+        _readiness = new ReadinessState(_registry.Object);
+        _lifetime = new ApplicationLifetime(NullLogger<ApplicationLifetime>.Instance);
+        _service = new WebSocketShutdownService(
+            _registry.Object,
+            _readiness,
+            _lifetime,
+            _logger.Object
+        );
+    }
+
+    [TearDown]
+    public async Task TearDown()
+    {
+        if (_app != null)
+            await _app.DisposeAsync();
+    }
+
+    /// <summary>
+    /// Starts a loopback server with the shutdown service registered as Program.cs does, whose
+    /// /ws endpoint tracks each socket and reads from it until the close handshake completes.
+    /// </summary>
+    private async Task<Uri> StartServer()
+    {
+        var builder = WebApplication.CreateBuilder();
+        builder.WebHost.UseUrls("http://127.0.0.1:0");
+        builder.Services.Configure<HostOptions>(options =>
+            options.ShutdownTimeout = TimeSpan.FromSeconds(5)
+        );
+        builder.Services.AddSingleton<ISignalRegistry>(serviceProvider => new SignalRegistry(
+            serviceProvider.GetRequiredService<ILogger<SignalRegistry>>()
+        ));
+        builder.Services.AddSingleton(serviceProvider => new ReadinessState(
+            serviceProvider.GetRequiredService<ISignalRegistry>()
+        ));
+        builder.Services.AddHostedService<WebSocketShutdownService>();
+
+        _app = builder.Build();
+        _app.UseWebSockets();
+        _app.Map(
+            "/ws",
+            async (HttpContext context, ISignalRegistry signalRegistry) =>
+            {
+                using var socket = await context.WebSockets.AcceptWebSocketAsync();
+                signalRegistry.TrackSocket(socket);
+                while (await socket.ReceiveFullMessageAsync(1024) != null) { }
+            }
+        );
+        await _app.StartAsync();
+
+        var address = _app
+            .Services.GetRequiredService<IServer>()
+            .Features.Get<IServerAddressesFeature>()!
+            .Addresses.First();
+        return new Uri(address.Replace("http://", "ws://") + "/ws");
+    }
+
+    [Test]
+    public async Task StoppingHost_SendsGoingAwayToConnectedClients()
+    {
+        var uri = await StartServer();
+        using var client = new ClientWebSocket();
+        await client.ConnectAsync(uri, CancellationToken.None);
+
+        var stopping = _app!.StopAsync();
+        using var timeout = new CancellationTokenSource(TimeSpan.FromSeconds(5));
+        var result = await client.ReceiveAsync(new byte[1024], timeout.Token);
+        await client.CloseOutputAsync(WebSocketCloseStatus.NormalClosure, null, timeout.Token);
+        await stopping;
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(result.MessageType, Is.EqualTo(WebSocketMessageType.Close));
+            Assert.That(client.CloseStatus, Is.EqualTo(WebSocketCloseStatus.EndpointUnavailable));
+        });
+    }
+
+    [Test]
+    public async Task ApplicationStopping_MarksInstanceNotReady()
+    {
+        await _service.StartAsync(CancellationToken.None);
+        Assert.That(_readiness.IsReady, Is.True);
+
+        _lifetime.StopApplication();
+
+        // Before any going-away frame: readiness flips while the server still serves traffic
+        Assert.That(_readiness.IsReady, Is.False);
+        _registry.Verify(r => r.GetTrackedSockets(), Times.Never);
+    }
+
+    [Test]
+    public void StoppingAsync_SocketError_DoesNotThrow()
+    {
+        var socket = new Mock<WebSocket>();
+        socket.Setup(s => s.State).Returns(WebSocketState.Open);
+        socket
+            .Setup(s =>
+                s.CloseOutputAsync(
+                    It.IsAny<WebSocketCloseStatus>(),
+                    It.IsAny<string>(),
+                    It.IsAny<CancellationToken>()
+                )
+            )
+            .ThrowsAsync(new WebSocketException("Connection reset"));
+
+        _registry.Setup(r => r.GetTrackedSockets()).Returns(new List<WebSocket> { socket.Object });
+
+        Assert.DoesNotThrowAsync(() => _service.StoppingAsync(CancellationToken.None));
+    }
+}
@@ -0,0 +1,189 @@
+using System.Net;
+using Microsoft.Extensions.Logging;
+using Moq;
+using SignalingServer.Configuration;
+using SignalingServer.Models;
+using SignalingServer.Services;
+using SignalingServer.Tests.Helpers;
+
+namespace SignalingServer.Tests;
+
+[TestFixture]
+public class WebhookNotifierTests
+{
+    private const string Secret = "webhook-secret";
+
+    /// <summary>
+    /// Records every delivered request and answers with the queued status codes, then 200.
+    /// </summary>
+    private class RecordingHttpMessageHandler(params HttpStatusCode[] statusCodes)
+        : HttpMessageHandler
+    {
+        private readonly Queue<HttpStatusCode> _statusCodes = new(statusCodes);
+
+        public List<(string Body, string? Signature)> Requests { get; } = new();
+
+        protected override async Task<HttpResponseMessage> SendAsync(
+            HttpRequestMessage request,
+            CancellationToken cancellationToken
+        )
+        {
+            var body = await request.Content!.ReadAsStringAsync(cancellationToken);
+            var signature = request.Headers.TryGetValues(WebhookNotifier.SignatureHeader, out var v)
+                ? v.Single()
+                : null;
+            lock (Requests)
+            {
+                Requests.Add((body, signature));
+            }
+
+            var status = _statusCodes.TryDequeue(out var code) ? code : HttpStatusCode.OK;
+            return new HttpResponseMessage(status);
+        }
+    }
+
+    private static WebhookOptions CreateOptions(int queueSize = 100) =>
+        new(
+            new Uri("https://hooks.example.com/signaling"),
+            Secret,
+            queueSize,
+            MaxAttempts: 3,
+            RetryDelay: TimeSpan.FromMilliseconds(1)
+        );
+
+    private static WebhookNotifier CreateNotifier(
+        RecordingHttpMessageHandler handler,
+        WebhookOptions? options = null
+    ) =>
+        new(
+            options ?? CreateOptions(),
+            new HttpClient(handler),
+            new Mock<ILogger<WebhookNotifier>>().Object
+        );
+
+    private static async Task WaitUntil(Func<bool> condition)
+    {
+        for (var i = 0; i < 100 && !condition(); i++)
+        {
+            await Task.Delay(20);
+        }
+    }
+
+    [Test]
+    public async Task Lifecycle_DeliversSignedEventsInOrder()
+    {
+        var handler = new RecordingHttpMessageHandler();
+        using var notifier = CreateNotifier(handler);
+        var registry = new SignalRegistry(
+            new Mock<ILogger<SignalRegistry>>().Object,
+            TimeProvider.System,
+            notifier
+        );
+        await notifier.StartAsync(CancellationToken.None);
+
+        var socket = new TestWebSocket();
+        registry.TrackSocket(socket);
+        registry.RegisterHost("HOST01", socket);
+        registry.RemoveHost(socket);
+        registry.UntrackSocket(socket);
+
+        await WaitUntil(() => handler.Requests.Count == 4);
+        await notifier.StopAsync(CancellationToken.None);
+
+        var events = handler.Requests.Select(r => r.Body.FromJson<PeerEvent>()!).ToList();
+        Assert.Multiple(() =>
+        {
+            Assert.That(
+                events.Select(e => e.Type),
+                Is.EqualTo(
+                    new[]
+                    {
+                        PeerEventTypes.PeerConnected,
+                        PeerEventTypes.RoomJoined,
+                        PeerEventTypes.RoomLeft,
+                        PeerEventTypes.PeerDisconnected,
+                    }
+                )
+            );
+            Assert.That(events.Select(e => e.ConnectionId).Distinct().Count(), Is.EqualTo(1));
+            Assert.That(events[1].HostId, Is.EqualTo("HOST01"));
+            Assert.That(events[1].Role, Is.EqualTo(PeerRole.Host));
+            Assert.That(
+                handler.Requests.Select(r => r.Signature),
+                Is.EqualTo(handler.Requests.Select(r => WebhookNotifier.Sign(Secret, r.Body)))
+            );
+        });
+    }
+
+    [Test]
+    public async Task DeliverAsync_RetriesUntilEndpointAccepts()
+    {
+        var handler = new RecordingHttpMessageHandler(
+            HttpStatusCode.ServiceUnavailable,
+            HttpStatusCode.InternalServerError
+        );
+        using var notifier = CreateNotifier(handler);
+
+        var delivered = await notifier.DeliverAsync(
+            new PeerEvent(PeerEventTypes.PeerConnected, DateTimeOffset.UnixEpoch, "conn-1"),
+            CancellationToken.None
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(delivered, Is.True);
+            Assert.That(handler.Requests, Has.Count.EqualTo(3));
+        });
+    }
+
+    [Test]
+    public async Task DeliverAsync_GivesUpAfterMaxAttempts()
+    {
+        var handler = new RecordingHttpMessageHandler(
+            HttpStatusCode.InternalServerError,
+            HttpStatusCode.InternalServerError,
+            HttpStatusCode.InternalServerError,
+            HttpStatusCode.InternalServerError
+        );
+        using var notifier = CreateNotifier(handler);
+
+        var delivered = await notifier.DeliverAsync(
+            new PeerEvent(PeerEventTypes.PeerConnected, DateTimeOffset.UnixEpoch, "conn-1"),
+            CancellationToken.None
+        );
+
+        Assert.Multiple(() =>
+        {
+            Assert.That(delivered, Is.False);
+            Assert.That(handler.Requests, Has.Count.EqualTo(3));
+        });
+    }
+
+    [Test]
+    public void Publish_WhenQueueIsFull_DropsWithoutBlocking()
+    {
+        var handler = new RecordingHttpMessageHandler();
+        using var notifier = CreateNotifier(handler, CreateOptions(queueSize: 1));
+
+        // The notifier isn't started, so nothing drains the queue
+        for (var i = 0; i < 10; i++)
+        {
+            notifier.Publish(
+                new PeerEvent(PeerEventTypes.PeerConnected, DateTimeOffset.UnixEpoch, $"conn-{i}")
+            );
+        }
+
+        Assert.That(handler.Requests, Is.Empty);
+    }
+
+    [Test]
+    public void Sign_ReturnsHexEncodedHmacSha256OfBody()
+    {
+        var signature = WebhookNotifier.Sign(Secret, """{"type":"peer-connected"}""");
+
+        Assert.That(
+            signature,
+            Is.EqualTo("sha256=ae1cb0cc6fe47bf2ca36f01dcdf10d3676e32ca4bb76c80a6e7d9b6e9e2cfeaa")
+        );
+    }
+}